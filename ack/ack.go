@@ -0,0 +1,136 @@
+// Package ack keeps track of which milestones people have taken care of
+// such as by sending a card, calling, or buying a gift.
+package ack
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/internal/atomicfile"
+)
+
+// Ack is the acknowledgement of one milestone.
+type Ack struct {
+
+	// The key of the milestone. See birthday.Milestone.Key.
+	Key string `json:"key"`
+
+	// When the milestone was acknowledged
+	Time time.Time `json:"time"`
+
+	// An optional note e.g "Sent card"
+	Note string `json:"note,omitempty"`
+}
+
+// Store stores acknowledgements in a JSON file. Store is safe to use
+// from multiple goroutines.
+type Store struct {
+	path string
+	mu   sync.Mutex
+	acks map[string]Ack
+}
+
+// Open opens the acknowledgements at path. If there is no file at path,
+// Open returns an empty store and creates the file on the first write.
+func Open(path string) (*Store, error) {
+	result := &Store{path: path, acks: make(map[string]Ack)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var acks []Ack
+	if err := json.Unmarshal(data, &acks); err != nil {
+		return nil, err
+	}
+	for _, a := range acks {
+		result.acks[a.Key] = a
+	}
+	return result, nil
+}
+
+// Get returns the acknowledgement of the milestone with key. Get returns
+// false if that milestone isn't acknowledged.
+func (s *Store) Get(key string) (Ack, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.acks[key]
+	return result, ok
+}
+
+// Acknowledge acknowledges the milestone with key replacing any previous
+// acknowledgement of it.
+func (s *Store) Acknowledge(key, note string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, existed := s.acks[key]
+	s.acks[key] = Ack{Key: key, Time: now, Note: note}
+	if err := s.save(); err != nil {
+		if existed {
+			s.acks[key] = old
+		} else {
+			delete(s.acks, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove removes the acknowledgement of the milestone with key if there
+// is one.
+func (s *Store) Remove(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, existed := s.acks[key]
+	if !existed {
+		return nil
+	}
+	delete(s.acks, key)
+	if err := s.save(); err != nil {
+		s.acks[key] = old
+		return err
+	}
+	return nil
+}
+
+// ForEntry returns the acknowledgements of the milestones of the entry
+// with id, most recent first.
+func (s *Store) ForEntry(id string) []Ack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Ack
+	for key, a := range s.acks {
+		entryId, _, _, err := birthday.ParseMilestoneKey(key)
+		if err == nil && entryId == id {
+			result = append(result, a)
+		}
+	}
+	slices.SortFunc(result, func(a, b Ack) int {
+		return b.Time.Compare(a.Time)
+	})
+	return result
+}
+
+func (s *Store) save() error {
+	acks := make([]Ack, 0, len(s.acks))
+	for _, a := range s.acks {
+		acks = append(acks, a)
+	}
+	slices.SortFunc(acks, func(a, b Ack) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	data, err := json.MarshalIndent(acks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.path, data, 0644, nil)
+}
@@ -0,0 +1,83 @@
+package ack_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/ack"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func open(t *testing.T, path string) *ack.Store {
+	result, err := ack.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStore(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "acks.json")
+	store := open(t, path)
+	_, ok := store.Get("abc-1y0m0w0d-40")
+	assert.False(ok)
+	first := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	assert.NoError(store.Acknowledge("abc-1y0m0w0d-40", "Sent card", first))
+	assert.NoError(store.Acknowledge("abc-0y100m0w0d-5", "", second))
+	assert.NoError(store.Acknowledge("abcd-1y0m0w0d-7", "Called", second))
+	assert.NoError(store.Acknowledge("xyz-1y0m0w0d-3", "", second))
+	assert.NoError(store.Remove("xyz-1y0m0w0d-3"))
+	assert.NoError(store.Remove("not-there"))
+
+	// Survives reopening
+	store = open(t, path)
+	a, ok := store.Get("abc-1y0m0w0d-40")
+	assert.True(ok)
+	assert.Equal(
+		ack.Ack{Key: "abc-1y0m0w0d-40", Time: first, Note: "Sent card"}, a)
+	_, ok = store.Get("xyz-1y0m0w0d-3")
+	assert.False(ok)
+	acks := store.ForEntry("abc")
+	if assert.Len(acks, 2) {
+		assert.Equal("abc-0y100m0w0d-5", acks[0].Key)
+		assert.Equal("abc-1y0m0w0d-40", acks[1].Key)
+	}
+
+	// Acknowledging again replaces the note
+	assert.NoError(store.Acknowledge("abc-1y0m0w0d-40", "Bought gift", second))
+	a, _ = store.Get("abc-1y0m0w0d-40")
+	assert.Equal("Bought gift", a.Note)
+}
+
+func TestForEntryIdPrefix(t *testing.T) {
+	assert := asserts.New(t)
+	store := open(t, filepath.Join(t.TempDir(), "acks.json"))
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	assert.NoError(store.Acknowledge("john-1y0m0w0d-40", "", now))
+	assert.NoError(store.Acknowledge("john-jr-1y0m0w0d-10", "", now))
+	acks := store.ForEntry("john")
+	if assert.Len(acks, 1) {
+		assert.Equal("john-1y0m0w0d-40", acks[0].Key)
+	}
+	acks = store.ForEntry("john-jr")
+	if assert.Len(acks, 1) {
+		assert.Equal("john-jr-1y0m0w0d-10", acks[0].Key)
+	}
+}
+
+func TestStoreWriteError(t *testing.T) {
+	assert := asserts.New(t)
+	dir := filepath.Join(t.TempDir(), "gone")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	store := open(t, filepath.Join(dir, "acks.json"))
+	os.Remove(dir)
+	assert.Error(store.Acknowledge("abc-1y0m0w0d-40", "", time.Now()))
+	_, ok := store.Get("abc-1y0m0w0d-40")
+	assert.False(ok)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"math"
 	"slices"
 	"sort"
 	"strconv"
@@ -22,6 +23,10 @@ const (
 	kInvalidPeriod = "invalid period"
 )
 
+var (
+	errInvalidKey = errors.New("invalid milestone key")
+)
+
 var (
 	// Currently yearly, 100 months, 100 weeks, 1000 days.
 	DefaultPeriods = []Period{
@@ -100,10 +105,38 @@ func HasYear(t time.Time) bool {
 	return t.Year() > 0
 }
 
+// DaysUntil returns the number of days from today until date. today and
+// date are dates at midnight in UTC.
+func DaysUntil(today, date time.Time) int {
+	return int(math.Round(date.Sub(today).Hours() / 24))
+}
+
+// When returns "today", "tomorrow" or "in N days" for days days from
+// now. For negative days, When returns "yesterday" or "N days ago".
+func When(days int) string {
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "tomorrow"
+	case days == -1:
+		return "yesterday"
+	case days < 0:
+		return fmt.Sprintf("%d days ago", -days)
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
 // Entry represents a single entry in the birthday database
 type Entry struct {
 	Name     string
 	Birthday time.Time
+
+	// Uniquely identifies this entry within its birthday file. Id comes
+	// from an explicit id=<id> field or, absent that, is derived from
+	// Name and Birthday.
+	Id string
 }
 
 // EntriesSortedByName returns entries sorted by name while leaving the
@@ -253,10 +286,71 @@ type Milestone struct {
 
 	// If true, age is unknown
 	AgeUnknown bool
+
+	// The period that produced this milestone
+	Period Period
+
+	// The number of periods from the birthday to Date
+	Count int
+}
+
+// MilestoneAt returns the milestone that falls count periods after the
+// birthday of entry. MilestoneAt returns false if count is negative or if
+// count is 0 and the year of birth is unknown.
+func MilestoneAt(entry *Entry, period Period, count int) (Milestone, bool) {
+	g := generator{entryPtr: entry, period: period, count: count, step: 1}
+	return g.Next()
+}
+
+// Key returns a string that identifies this milestone by the Id of its
+// entry, its period, and its count e.g "abc-1y0m0w0d-57".
+func (m *Milestone) Key() string {
+	p := m.Period
+	normalize := ""
+	if p.Normalize {
+		normalize = "n"
+	}
+	return fmt.Sprintf(
+		"%s-%dy%dm%dw%dd%s-%d",
+		m.EntryPtr.Id, p.Years, p.Months, p.Weeks, p.Days, normalize, m.Count)
+}
+
+// ParseMilestoneKey parses a key returned by Milestone.Key.
+func ParseMilestoneKey(key string) (
+	entryId string, period Period, count int, err error) {
+	countIdx := strings.LastIndexByte(key, '-')
+	if countIdx == -1 {
+		return "", Period{}, 0, errInvalidKey
+	}
+	periodIdx := strings.LastIndexByte(key[:countIdx], '-')
+	if periodIdx <= 0 {
+		return "", Period{}, 0, errInvalidKey
+	}
+	count, err = strconv.Atoi(key[countIdx+1:])
+	if err != nil {
+		return "", Period{}, 0, errInvalidKey
+	}
+	periodStr := key[periodIdx+1 : countIdx]
+	if rest, ok := strings.CutSuffix(periodStr, "n"); ok {
+		periodStr = rest
+		period.Normalize = true
+	}
+	n, err := fmt.Sscanf(
+		periodStr,
+		"%dy%dm%dw%dd",
+		&period.Years, &period.Months, &period.Weeks, &period.Days)
+	if err != nil || n != 4 || !period.Valid() {
+		return "", Period{}, 0, errInvalidKey
+	}
+	return key[:periodIdx], period, count, nil
 }
 
 // Less orders Milestones. Less orders first by Date then by Name
-// then by AgeUnknown and finally by Age.
+// then by AgeUnknown then by Age and finally by entry Id so that
+// different people with the same name and birthday never compare equal.
+// Milestones of the same person with the same date and age compare equal
+// even if they come from different periods so that Remind shows them
+// only once.
 func (m *Milestone) Less(other *Milestone) bool {
 	if m.Date.Before(other.Date) {
 		return true
@@ -276,7 +370,13 @@ func (m *Milestone) Less(other *Milestone) bool {
 	if m.AgeUnknown && !other.AgeUnknown {
 		return false
 	}
-	return m.Age.Less(other.Age)
+	if m.Age.Less(other.Age) {
+		return true
+	}
+	if other.Age.Less(m.Age) {
+		return false
+	}
+	return m.EntryPtr.Id < other.EntryPtr.Id
 }
 
 // AgeString returns the age as a string e.g "57 years"
@@ -307,22 +407,22 @@ func Remind(
 	periods []Period,
 	current time.Time) iter.Seq[Milestone] {
 	checkPeriods(periods)
-	base := createMilestoneBase(entries, periods, current)
+	base := createMilestoneBase(entries, periods, current, false)
 	if len(base) == 0 {
 		return itertools.Chain[Milestone]()
 	}
 	return func(yield func(Milestone) bool) {
-		mh := createMilestoneHeap(base)
-		milestone := mh[0].Milestone
+		mh := createMilestoneHeap(base, false)
+		milestone := mh.Generators[0].Milestone
 		for {
 			if !yield(milestone) {
 				return
 			}
-			for !milestone.Less(&mh[0].Milestone) {
-				mh[0].Advance()
+			for !milestone.Less(&mh.Generators[0].Milestone) {
+				mh.Generators[0].Advance()
 				heap.Fix(&mh, 0)
 			}
-			milestone = mh[0].Milestone
+			milestone = mh.Generators[0].Milestone
 		}
 	}
 }
@@ -337,10 +437,53 @@ func RemindPtrs(
 		Remind(entries, periods, current))
 }
 
+// History returns all past Milestones for the specified entries and
+// periods that fall before the date specified by current. History returns
+// Milestone instances in reverse chronological order ending with the
+// birth of each person.
+func History(
+	entries []*Entry,
+	periods []Period,
+	current time.Time) iter.Seq[Milestone] {
+	checkPeriods(periods)
+	base := createMilestoneBase(entries, periods, current, true)
+	if len(base) == 0 {
+		return itertools.Chain[Milestone]()
+	}
+	return func(yield func(Milestone) bool) {
+		mh := createMilestoneHeap(base, true)
+		for len(mh.Generators) > 0 {
+			milestone := mh.Generators[0].Milestone
+			if !yield(milestone) {
+				return
+			}
+			for len(mh.Generators) > 0 &&
+				!mh.Generators[0].Milestone.Less(&milestone) {
+				if mh.Generators[0].Advance() {
+					heap.Fix(&mh, 0)
+				} else {
+					heap.Pop(&mh)
+				}
+			}
+		}
+	}
+}
+
+// HistoryPtrs works like History except that it returns Milestone pointers.
+func HistoryPtrs(
+	entries []*Entry,
+	periods []Period,
+	current time.Time) iter.Seq[*Milestone] {
+	return itertools.Map(
+		func(m Milestone) *Milestone { return &m },
+		History(entries, periods, current))
+}
+
 func createMilestoneBase(
 	entries []*Entry,
 	periods []Period,
-	current time.Time) []milestoneGenerator {
+	current time.Time,
+	past bool) []milestoneGenerator {
 	size := 0
 	for i := range entries {
 		hasYear := HasYear(entries[i].Birthday)
@@ -359,19 +502,24 @@ func createMilestoneBase(
 		hasYear := HasYear(entries[i].Birthday)
 		for j := range periods {
 			if hasYear || periods[j] == yearly {
-				result[index].Init(entries[i], periods[j], current)
-				index++
+				if result[index].Init(entries[i], periods[j], current, past) {
+					index++
+				}
 			}
 		}
 	}
-	return result
+	return result[:index]
 }
 
-func createMilestoneHeap(base []milestoneGenerator) milestoneHeap {
+func createMilestoneHeap(
+	base []milestoneGenerator, reverse bool) milestoneHeap {
 	allocatedSpace := slices.Clone(base)
-	result := make(milestoneHeap, len(allocatedSpace))
-	for i := range result {
-		result[i] = &allocatedSpace[i]
+	result := milestoneHeap{
+		Generators: make([]*milestoneGenerator, len(allocatedSpace)),
+		Reverse:    reverse,
+	}
+	for i := range result.Generators {
+		result.Generators[i] = &allocatedSpace[i]
 	}
 	heap.Init(&result)
 	return result
@@ -382,34 +530,52 @@ type milestoneGenerator struct {
 	Milestone Milestone
 }
 
+// Init initializes this instance. If past is true, this instance
+// generates milestones going backward in time. Init returns false if
+// there are no milestones to generate.
 func (gm *milestoneGenerator) Init(
-	entry *Entry, period Period, current time.Time) {
-	gm.Generator.Init(entry, period, current)
-	gm.Advance()
+	entry *Entry, period Period, current time.Time, past bool) bool {
+	gm.Generator.Init(entry, period, current, past)
+	return gm.Advance()
 }
 
-func (gm *milestoneGenerator) Advance() {
-	gm.Milestone = gm.Generator.Next()
+// Advance advances to the next milestone. Advance returns false if
+// there are no more milestones.
+func (gm *milestoneGenerator) Advance() bool {
+	var ok bool
+	gm.Milestone, ok = gm.Generator.Next()
+	return ok
 }
 
 type generator struct {
 	entryPtr *Entry
 	period   Period
 	count    int
+	step     int
 }
 
 func (g *generator) Init(
-	entry *Entry, period Period, current time.Time) {
+	entry *Entry, period Period, current time.Time, past bool) {
 	yesterday := current.AddDate(0, 0, -1)
-	count := period.Diff(yesterday, entry.Birthday) + 1
-	if count < 0 {
+	count := period.Diff(yesterday, entry.Birthday)
+	step := -1
+	if !past {
+		count++
+		step = 1
+	}
+	if count < 0 && !past {
 		count = 0
 	}
-	*g = generator{entryPtr: entry, period: period, count: count}
+	*g = generator{entryPtr: entry, period: period, count: count, step: step}
 }
 
-func (g *generator) Next() Milestone {
+func (g *generator) Next() (Milestone, bool) {
 	hasYear := HasYear(g.entryPtr.Birthday)
+
+	// Birth itself is a milestone only if we know the year.
+	if g.count < 0 || (g.count == 0 && !hasYear) {
+		return Milestone{}, false
+	}
 	var age Period
 	if hasYear {
 		age = g.period.Multiply(g.count)
@@ -420,35 +586,45 @@ func (g *generator) Next() Milestone {
 		Date:       nextMilestone,
 		Age:        age,
 		AgeUnknown: !hasYear,
+		Period:     g.period,
+		Count:      g.count,
 	}
-	g.count++
-	return result
+	g.count += g.step
+	return result, true
 }
 
-type milestoneHeap []*milestoneGenerator
+type milestoneHeap struct {
+	Generators []*milestoneGenerator
+
+	// If true, latest milestone comes first.
+	Reverse bool
+}
 
 func (m milestoneHeap) Less(i, j int) bool {
-	return m[i].Milestone.Less(&m[j].Milestone)
+	if m.Reverse {
+		i, j = j, i
+	}
+	return m.Generators[i].Milestone.Less(&m.Generators[j].Milestone)
 }
 
 func (m milestoneHeap) Swap(i, j int) {
-	m[i], m[j] = m[j], m[i]
+	m.Generators[i], m.Generators[j] = m.Generators[j], m.Generators[i]
 }
 
 func (m milestoneHeap) Len() int {
-	return len(m)
+	return len(m.Generators)
 }
 
 func (m *milestoneHeap) Push(x interface{}) {
 	mg := x.(*milestoneGenerator)
-	*m = append(*m, mg)
+	m.Generators = append(m.Generators, mg)
 }
 
 func (m *milestoneHeap) Pop() interface{} {
-	old := *m
+	old := m.Generators
 	n := len(old)
 	x := old[n-1]
-	*m = old[0 : n-1]
+	m.Generators = old[0 : n-1]
 	return x
 }
 
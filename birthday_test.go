@@ -143,6 +143,37 @@ func TestMilestoneLess(t *testing.T) {
 	assert.False(rhs.Less(&lhs))
 }
 
+func TestMilestoneLessById(t *testing.T) {
+	assert := asserts.New(t)
+	lhs := birthday.Milestone{
+		EntryPtr: &birthday.Entry{Name: "John", Id: "john1"}}
+	rhs := birthday.Milestone{
+		EntryPtr: &birthday.Entry{Name: "John", Id: "john2"}}
+	assert.True(lhs.Less(&rhs))
+	assert.False(rhs.Less(&lhs))
+}
+
+func TestRemindSameNameAndBirthday(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "John", Birthday: date_util.YMD(1990, 6, 1), Id: "john1"},
+		{Name: "John", Birthday: date_util.YMD(1990, 6, 1), Id: "john2"},
+	}
+	var ids []string
+	var years []int
+	seq := itertools.Take(
+		6,
+		birthday.RemindPtrs(
+			entries, []birthday.Period{kYears}, date_util.YMD(2026, 1, 1)))
+	for m := range seq {
+		ids = append(ids, m.EntryPtr.Id)
+		years = append(years, m.Date.Year())
+	}
+	assert.Equal(
+		[]string{"john1", "john2", "john1", "john2", "john1", "john2"}, ids)
+	assert.Equal([]int{2026, 2026, 2027, 2027, 2028, 2028}, years)
+}
+
 func TestPeriodAdd(t *testing.T) {
 	assert := asserts.New(t)
 	p := birthday.Period{Years: 2, Months: 1, Weeks: 2, Days: -11}
@@ -650,6 +681,141 @@ func TestRemindAgain(t *testing.T) {
 		milestones)
 }
 
+func TestRemindPeriodAndCount(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Matt", Birthday: date_util.YMD(2021, 2, 4)},
+	}
+	milestones := slices.Collect(
+		itertools.Take(
+			2,
+			birthday.Remind(
+				entries,
+				[]birthday.Period{kYears, kThousandDays},
+				date_util.YMD(2023, 10, 1))))
+	assert.Equal(kThousandDays, milestones[0].Period)
+	assert.Equal(1, milestones[0].Count)
+	assert.Equal(kYears, milestones[1].Period)
+	assert.Equal(3, milestones[1].Count)
+}
+
+func TestMilestoneAt(t *testing.T) {
+	assert := asserts.New(t)
+	entry := &birthday.Entry{
+		Name: "Matt", Birthday: date_util.YMD(2021, 2, 4), Id: "matt"}
+	m, ok := birthday.MilestoneAt(entry, kSixMonths, 3)
+	assert.True(ok)
+	assert.Equal(date_util.YMD(2022, 8, 4), m.Date)
+	assert.Equal(birthday.Period{Years: 1, Months: 6}, m.Age)
+	assert.Equal("matt-0y6m0w0dn-3", m.Key())
+	_, ok = birthday.MilestoneAt(entry, kYears, -1)
+	assert.False(ok)
+	noYear := &birthday.Entry{Name: "Mark", Birthday: date_util.YMD(0, 2, 5)}
+	_, ok = birthday.MilestoneAt(noYear, kYears, 0)
+	assert.False(ok)
+	m, ok = birthday.MilestoneAt(noYear, kYears, 2025)
+	assert.True(ok)
+	assert.Equal(date_util.YMD(2025, 2, 5), m.Date)
+	assert.True(m.AgeUnknown)
+}
+
+func TestParseMilestoneKey(t *testing.T) {
+	assert := asserts.New(t)
+	id, period, count, err := birthday.ParseMilestoneKey("a-b-0y6m0w0dn-3")
+	assert.NoError(err)
+	assert.Equal("a-b", id)
+	assert.Equal(kSixMonths, period)
+	assert.Equal(3, count)
+	id, period, count, err = birthday.ParseMilestoneKey("matt-0y0m0w1000d-12")
+	assert.NoError(err)
+	assert.Equal("matt", id)
+	assert.Equal(kThousandDays, period)
+	assert.Equal(12, count)
+	_, _, _, err = birthday.ParseMilestoneKey("matt-0y0m0w0d-12")
+	assert.Error(err)
+	_, _, _, err = birthday.ParseMilestoneKey("-1y0m0w0d-12")
+	assert.Error(err)
+	_, _, _, err = birthday.ParseMilestoneKey("matt-1y0m0w0d-x")
+	assert.Error(err)
+	_, _, _, err = birthday.ParseMilestoneKey("matt")
+	assert.Error(err)
+}
+
+func TestHistory(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Matt", Birthday: date_util.YMD(2021, 2, 4)},
+		{Name: "Mark", Birthday: date_util.YMD(0, 2, 5)},
+	}
+	seq := itertools.Map(
+		toTestMilestone,
+		birthday.HistoryPtrs(
+			entries,
+			[]birthday.Period{kYears, kThousandDays, kHundredWeeks},
+			date_util.YMD(2024, 2, 5)))
+	assert.Equal(
+		[]testMilestone{
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2024, 2, 4),
+				Age:  birthday.Period{Years: 3},
+			},
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2023, 11, 1),
+				Age:  birthday.Period{Days: 1000},
+			},
+			{
+				Name:       "Mark",
+				Date:       date_util.YMD(2023, 2, 5),
+				AgeUnknown: true,
+			},
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2023, 2, 4),
+				Age:  birthday.Period{Years: 2},
+			},
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2023, 1, 5),
+				Age:  birthday.Period{Weeks: 100},
+			},
+		},
+		slices.Collect(itertools.Take(5, seq)))
+}
+
+func TestHistoryEndsAtBirth(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Matt", Birthday: date_util.YMD(2021, 2, 4)},
+	}
+	assert.Equal(
+		[]testMilestone{
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2022, 2, 4),
+				Age:  birthday.Period{Years: 1},
+			},
+			{
+				Name: "Matt",
+				Date: date_util.YMD(2021, 2, 4),
+			},
+		},
+		slices.Collect(
+			itertools.Map(
+				toTestMilestone,
+				birthday.HistoryPtrs(
+					entries,
+					[]birthday.Period{kYears, kThousandDays},
+					date_util.YMD(2022, 2, 5)))))
+	assert.Empty(
+		slices.Collect(
+			birthday.History(
+				entries,
+				[]birthday.Period{kYears},
+				date_util.YMD(2021, 2, 4))))
+}
+
 func TestFilterNone(t *testing.T) {
 	assert := asserts.New(t)
 	queryFunc := birthday.Query("")
@@ -762,3 +928,17 @@ func getMilestones(
 		currentDate,
 		daysAhead)
 }
+
+func TestDaysUntilAndWhen(t *testing.T) {
+	assert := asserts.New(t)
+	today := date_util.YMD(2024, 3, 1)
+	assert.Equal(0, birthday.DaysUntil(today, today))
+	assert.Equal(1, birthday.DaysUntil(today, date_util.YMD(2024, 3, 2)))
+	assert.Equal(-1, birthday.DaysUntil(today, date_util.YMD(2024, 2, 29)))
+	assert.Equal(366, birthday.DaysUntil(today, date_util.YMD(2025, 3, 2)))
+	assert.Equal("today", birthday.When(0))
+	assert.Equal("tomorrow", birthday.When(1))
+	assert.Equal("in 7 days", birthday.When(7))
+	assert.Equal("yesterday", birthday.When(-1))
+	assert.Equal("3 days ago", birthday.When(-3))
+}
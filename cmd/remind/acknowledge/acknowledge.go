@@ -0,0 +1,71 @@
+// Package acknowledge handles marking milestones as done.
+package acknowledge
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ack"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	// XsrfAction is the action of the xsrf tokens that forms posting to
+	// /ack must include.
+	XsrfAction = "/ack"
+
+	// XsrfExpire is how long xsrf tokens for /ack last.
+	XsrfExpire = time.Hour
+
+	kMaxNoteLength = 200
+)
+
+// Handler serves /ack which takes POST requests to acknowledge the
+// milestone given by the key parameter with an optional note. With an
+// undo parameter, Handler removes the acknowledgement instead. Afterwards
+// Handler redirects to the page in the return parameter, /home by
+// default.
+type Handler struct {
+	Acks  *ack.Store
+	Xsrf  *common.Xsrf
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.Method != http.MethodPost {
+		http_util.Error(w, http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.Xsrf.VerifyToken(r.Form.Get("xsrf"), XsrfAction, h.Clock.Now()) {
+		http.Error(
+			w,
+			"Form expired. Please go back, reload and try again.",
+			http.StatusForbidden)
+		return
+	}
+	key := r.Form.Get("key")
+	if _, _, _, err := birthday.ParseMilestoneKey(key); err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	var err error
+	if http_util.HasParam(r.Form, "undo") {
+		err = h.Acks.Remove(key)
+	} else {
+		note := strings.TrimSpace(r.Form.Get("note"))
+		if runes := []rune(note); len(runes) > kMaxNoteLength {
+			note = string(runes[:kMaxNoteLength])
+		}
+		err = h.Acks.Acknowledge(key, note, h.Clock.Now())
+	}
+	if err != nil {
+		http_util.ReportError(w, "Error saving acknowledgement", err)
+		return
+	}
+	http_util.Redirect(w, r, common.ReturnPath(r.Form.Get("return")))
+}
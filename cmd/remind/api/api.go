@@ -0,0 +1,162 @@
+// Package api serves the JSON REST API of the remind server.
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/toolbox/date_util"
+)
+
+// MilestonesHandler serves /api/v1/milestones. It accepts the same q, p,
+// date and days parameters as /home along with limit, cursor and before
+// parameters for paging.
+type MilestonesHandler struct {
+	Store          birthday.Store
+	DaysAhead      int
+	DefaultLimit   int
+	MaxLimit       int
+	DefaultPeriods []birthday.Period
+	Mute           *mute.Rules
+	Clock          date_util.Clock
+}
+
+func (h *MilestonesHandler) ServeHTTP(
+	w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r) {
+		return
+	}
+	r.ParseForm()
+	periodStr := r.Form.Get("p")
+	if err := common.CheckPeriods(periodStr); err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	today, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	daysAhead, err := parseInt(r.Form.Get("days"), h.DaysAhead, 0)
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, "invalid days")
+		return
+	}
+	limit, err := parseInt(r.Form.Get("limit"), h.DefaultLimit, 1)
+	if err != nil || limit > h.MaxLimit {
+		common.WriteJSONError(
+			w,
+			http.StatusBadRequest,
+			fmt.Sprintf("limit must be between 1 and %d", h.MaxLimit))
+		return
+	}
+	after, err := common.ParseCursorParam(r.Form.Get("cursor"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := common.ParseCursorParam(r.Form.Get("before"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		common.WriteJSONError(
+			w, http.StatusInternalServerError, "error reading birthday file")
+		return
+	}
+	endDate := today.AddDate(0, 0, daysAhead)
+	start := today
+	if after != nil {
+		start = after.Date
+	}
+	page := common.Paginate(
+		entries,
+		h.Mute.Filter(common.Milestones(
+			entries,
+			common.ParsePeriods(periodStr, h.DefaultPeriods),
+			start,
+			endDate)),
+		after,
+		before,
+		limit)
+	common.WriteJSON(
+		w, http.StatusOK, common.NewMilestonesJSON(page, today, endDate))
+}
+
+// EntriesHandler serves /api/v1/entries. It accepts q and date
+// parameters like /search along with an id parameter to fetch a single
+// entry. Ages are as of date.
+type EntriesHandler struct {
+	Store birthday.Store
+	Clock date_util.Clock
+}
+
+func (h *EntriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r) {
+		return
+	}
+	r.ParseForm()
+	today, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		common.WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if id := r.Form.Get("id"); id != "" {
+		entry, ok, err := common.FindEntry(h.Store, id)
+		if err != nil {
+			common.WriteJSONError(
+				w, http.StatusInternalServerError, "error reading birthday file")
+			return
+		}
+		if !ok {
+			common.WriteJSONError(w, http.StatusNotFound, "no such id")
+			return
+		}
+		common.WriteJSON(
+			w, http.StatusOK, common.NewEntryJSONWithAges(&entry, today))
+		return
+	}
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		common.WriteJSONError(
+			w, http.StatusInternalServerError, "error reading birthday file")
+		return
+	}
+	common.WriteJSON(
+		w,
+		http.StatusOK,
+		common.NewEntriesJSON(birthday.EntriesSortedByName(entries), today))
+}
+
+func checkMethod(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		common.WriteJSONError(
+			w, http.StatusMethodNotAllowed, "method not allowed")
+		return false
+	}
+	return true
+}
+
+// parseInt parses s as an int no smaller than minValue. If s is empty,
+// parseInt returns defaultValue.
+func parseInt(s string, defaultValue, minValue int) (int, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	result, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if result < minValue {
+		return 0, fmt.Errorf("must be at least %d", minValue)
+	}
+	return result, nil
+}
@@ -0,0 +1,242 @@
+// Package caldav serves the milestones of the remind server as a
+// read-only CalDAV calendar.
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/cmd/remind/dav"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kCalendarName   = "Birthdays"
+	kCollectionName = "birthdays/"
+	kContentType    = "text/calendar; charset=utf-8"
+)
+
+var (
+	// kStamp is the DTSTAMP of every event. It never changes so that the
+	// data of an event, and so its ETag, change only when the event does.
+	kStamp = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Handler serves a read-only CalDAV calendar of milestones. Handler
+// serves the principal and calendar home at Prefix and the one calendar
+// collection at Prefix + "birthdays/". Each milestone is a resource named
+// after its key. Handler supports OPTIONS, PROPFIND, REPORT
+// calendar-query with time-range, REPORT calendar-multiget and GET.
+type Handler struct {
+	Store birthday.Store
+
+	// e.g "/caldav/"
+	Prefix string
+
+	// PROPFIND and calendar-query without a time-range cover this
+	// many days starting today.
+	DaysAhead int
+
+	// The maximum number of events returned in one response
+	MaxRows int
+
+	Periods []birthday.Period
+
+	// Used to make event UIDs globally unique
+	Domain string
+
+	// Optional. Rules for hiding milestones
+	Mute *mute.Rules
+
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := &dav.Handler{
+		Prefix:         h.Prefix,
+		CollectionName: kCollectionName,
+		Compliance:     "1, 3, calendar-access",
+		HomeProps: []dav.Property{
+			dav.Prop(dav.NSDAV, "resourcetype", "<D:collection/>"),
+			dav.Prop(dav.NSDAV, "displayname", dav.Escape(kCalendarName)),
+			dav.Prop(dav.NSDAV, "current-user-principal", dav.Href(h.Prefix)),
+			dav.Prop(dav.NSDAV, "principal-URL", dav.Href(h.Prefix)),
+			dav.Prop(dav.NSCalDAV, "calendar-home-set", dav.Href(h.Prefix)),
+		},
+		Query:       xml.Name{Space: dav.NSCalDAV, Local: "calendar-query"},
+		Multiget:    xml.Name{Space: dav.NSCalDAV, Local: "calendar-multiget"},
+		Data:        xml.Name{Space: dav.NSCalDAV, Local: "calendar-data"},
+		ContentType: kContentType,
+		Open:        h.open,
+	}
+	isGet := r.Method == http.MethodGet || r.Method == http.MethodHead
+	if isGet && r.URL.Path == handler.CollectionPath() {
+		h.getCalendar(w)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+// getCalendar writes the milestones of the next DaysAhead days as one
+// calendar.
+func (h *Handler) getCalendar(w http.ResponseWriter) {
+	entries, err := common.ReadEntries(h.Store, "")
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	milestones, _ := h.window(entries, nil)
+	w.Header().Set("Content-Type", kContentType)
+	h.calendar(kCalendarName).Write(w, slices.Values(milestones))
+}
+
+func (h *Handler) open() (dav.Collection, error) {
+	entries, err := common.ReadEntries(h.Store, "")
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*birthday.Entry, len(entries))
+	for _, entry := range entries {
+		byId[entry.Id] = entry
+	}
+	return &collection{handler: h, entries: entries, byId: byId}, nil
+}
+
+// collection is the calendar of milestones as of a single request.
+type collection struct {
+	handler *Handler
+	entries []*birthday.Entry
+
+	// maps ids to entries
+	byId map[string]*birthday.Entry
+}
+
+func (c *collection) Props() []dav.Property {
+	milestones, _ := c.handler.window(c.entries, nil)
+	return []dav.Property{
+		dav.Prop(
+			dav.NSDAV, "resourcetype", "<D:collection/><C:calendar/>"),
+		dav.Prop(dav.NSDAV, "displayname", dav.Escape(kCalendarName)),
+		dav.Prop(
+			dav.NSDAV, "current-user-principal", dav.Href(c.handler.Prefix)),
+		dav.Prop(
+			dav.NSDAV,
+			"current-user-privilege-set",
+			"<D:privilege><D:read/></D:privilege>"),
+		dav.Prop(
+			dav.NSCalDAV,
+			"supported-calendar-component-set",
+			`<C:comp name="VEVENT"/>`),
+		dav.Prop(
+			dav.NSCalendarServer,
+			"getctag",
+			dav.Escape(dav.CTag(c.handler.resources(milestones)))),
+	}
+}
+
+func (c *collection) Resources(query *dav.Report) ([]*dav.Resource, error) {
+	var timeRange map[string]string
+	if query != nil {
+		timeRange = query.TimeRange
+	}
+	milestones, err := c.handler.window(c.entries, timeRange)
+	if err != nil {
+		return nil, err
+	}
+	return c.handler.resources(milestones), nil
+}
+
+// Find returns the milestone with the key in name. Milestones that
+// Mute hides don't exist.
+func (c *collection) Find(name string) (*dav.Resource, bool) {
+	key, ok := strings.CutSuffix(name, ".ics")
+	if !ok {
+		return nil, false
+	}
+	entryId, period, count, err := birthday.ParseMilestoneKey(key)
+	if err != nil {
+		return nil, false
+	}
+	entry, ok := c.byId[entryId]
+	if !ok {
+		return nil, false
+	}
+	m, ok := birthday.MilestoneAt(entry, period, count)
+	if !ok || c.handler.Mute.Hides(&m) {
+		return nil, false
+	}
+	return c.handler.resource(&m), true
+}
+
+func (h *Handler) resources(
+	milestones []*birthday.Milestone) []*dav.Resource {
+	result := make([]*dav.Resource, 0, len(milestones))
+	for _, m := range milestones {
+		result = append(result, h.resource(m))
+	}
+	return result
+}
+
+func (h *Handler) resource(m *birthday.Milestone) *dav.Resource {
+	var buf bytes.Buffer
+	h.calendar("").Write(&buf, slices.Values([]*birthday.Milestone{m}))
+	return &dav.Resource{Name: m.Key() + ".ics", Data: buf.String()}
+}
+
+// window returns the milestones of entries in timeRange, the start and
+// end attributes of a CalDAV time-range element. If timeRange is nil,
+// window returns the milestones from today through DaysAhead days. If
+// timeRange has only a start, window returns the milestones from start
+// through DaysAhead days; if it has only an end, the milestones from
+// DaysAhead days before end through end. window returns dav.ErrBadQuery
+// if it can't parse timeRange.
+func (h *Handler) window(
+	entries []*birthday.Entry,
+	timeRange map[string]string) ([]*birthday.Milestone, error) {
+	start := birthday.Today(h.Clock)
+	end := start.AddDate(0, 0, h.DaysAhead)
+	startStr, hasStart := timeRange["start"]
+	endStr, hasEnd := timeRange["end"]
+	var err error
+	if hasStart {
+		if start, err = parseUTC(startStr); err != nil {
+			return nil, dav.ErrBadQuery
+		}
+		end = start.AddDate(0, 0, h.DaysAhead)
+	}
+	if hasEnd {
+		if end, err = parseUTC(endStr); err != nil {
+			return nil, dav.ErrBadQuery
+		}
+		if !hasStart {
+			start = end.AddDate(0, 0, -h.DaysAhead)
+		}
+	}
+
+	// An all-day event overlaps the time range if it falls on or after the
+	// day start falls on and before end.
+	startDay := date_util.TimeToDate(start.UTC())
+	return slices.Collect(
+		itertools.Take(
+			h.MaxRows,
+			h.Mute.Filter(
+				common.Milestones(entries, h.Periods, startDay, end)))), nil
+}
+
+func (h *Handler) calendar(name string) *ical.Calendar {
+	return &ical.Calendar{Name: name, Domain: h.Domain, Stamp: kStamp}
+}
+
+func parseUTC(s string) (time.Time, error) {
+	return time.Parse("20060102T150405Z", s)
+}
@@ -0,0 +1,166 @@
+package caldav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/caldav"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+const (
+	kJohnHref = "/caldav/birthdays/john-1y0m0w0d-57.ics"
+)
+
+var (
+	kEntries = []birthday.Entry{
+		{Name: "John Smith", Birthday: date_util.YMD(1967, 3, 25), Id: "john"},
+		{Name: "Merna", Birthday: date_util.YMD(0, 5, 17), Id: "merna"},
+	}
+)
+
+type entriesStore []birthday.Entry
+
+func (s entriesStore) Read(consumer consume2.Consumer[birthday.Entry]) error {
+	for _, entry := range s {
+		if !consumer.CanConsume() {
+			break
+		}
+		consumer.Consume(entry)
+	}
+	return nil
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func newHandler() *caldav.Handler {
+	return &caldav.Handler{
+		Store:     entriesStore(kEntries),
+		Prefix:    "/caldav/",
+		DaysAhead: 30,
+		MaxRows:   100,
+		Periods:   []birthday.Period{{Years: 1}},
+		Domain:    "example.com",
+		Clock:     fixedClock(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+	}
+}
+
+func query(h http.Handler, timeRange string) *httptest.ResponseRecorder {
+	body := `<?xml version="1.0"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">
+    ` + timeRange + `
+  </C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`
+	w := httptest.NewRecorder()
+	h.ServeHTTP(
+		w,
+		httptest.NewRequest(
+			"REPORT", "/caldav/birthdays/", strings.NewReader(body)))
+	return w
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestCalendarProps(t *testing.T) {
+	assert := asserts.New(t)
+	w := httptest.NewRecorder()
+	newHandler().ServeHTTP(
+		w, httptest.NewRequest("PROPFIND", "/caldav/birthdays/", nil))
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	assert.Contains(w.Body.String(), "<D:collection/><C:calendar/>")
+	assert.Contains(w.Body.String(), `<C:comp name="VEVENT"/>`)
+	assert.Contains(w.Body.String(), "<CS:getctag>")
+}
+
+func TestCalendarQuery(t *testing.T) {
+	assert := asserts.New(t)
+	h := newHandler()
+
+	// Without a time-range, only John Smith's birthday falls in the next
+	// 30 days.
+	w := query(h, "")
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	assert.Contains(w.Body.String(), "<D:href>"+kJohnHref+"</D:href>")
+
+	w = query(
+		h, `<C:time-range start="20250301T000000Z" end="20250401T000000Z"/>`)
+	assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	assert.Contains(w.Body.String(), "John Smith: 58 years")
+
+	// Only a start covers DaysAhead days from start.
+	w = query(h, `<C:time-range start="20240501T000000Z"/>`)
+	assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	assert.Contains(w.Body.String(), "Merna: ? years")
+
+	// Only an end covers DaysAhead days before end.
+	w = query(h, `<C:time-range end="20240601T000000Z"/>`)
+	assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+	assert.Contains(w.Body.String(), "Merna: ? years")
+
+	w = query(h, `<C:time-range start="yesterday"/>`)
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+func TestGetEvent(t *testing.T) {
+	assert := asserts.New(t)
+	h := newHandler()
+	w := get(h, kJohnHref)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(w.Body.String(), "SUMMARY:John Smith: 57 years")
+	assert.Contains(w.Body.String(), "UID:john-1y0m0w0d-57@example.com")
+
+	// The same event has the same data and ETag no matter when it is
+	// fetched.
+	h.Clock = fixedClock(time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC))
+	later := get(h, kJohnHref)
+	assert.Equal(w.Body.String(), later.Body.String())
+	assert.Equal(w.Header().Get("ETag"), later.Header().Get("ETag"))
+
+	w = get(h, "/caldav/birthdays/nobody-1y0m0w0d-3.ics")
+	assert.Equal(http.StatusNotFound, w.Code)
+	w = get(h, "/caldav/birthdays/john.ics")
+	assert.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestGetCalendar(t *testing.T) {
+	assert := asserts.New(t)
+	w := get(newHandler(), "/caldav/birthdays/")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), "X-WR-CALNAME:Birthdays")
+	assert.Equal(1, strings.Count(w.Body.String(), "BEGIN:VEVENT"))
+}
+
+func TestMutedEvent(t *testing.T) {
+	assert := asserts.New(t)
+	rules, err := mute.Open(filepath.Join(t.TempDir(), "mute.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(rules.Add(mute.Rule{Key: "john-1y0m0w0d-57"}))
+	h := newHandler()
+	h.Mute = rules
+	w := get(h, kJohnHref)
+	assert.Equal(http.StatusNotFound, w.Code)
+	w = query(h, "")
+	assert.NotContains(w.Body.String(), "BEGIN:VEVENT")
+}
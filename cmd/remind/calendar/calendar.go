@@ -0,0 +1,207 @@
+package calendar
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/grid"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Birthdays</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  th {
+    font-size: 30px;
+  }
+  td {
+    font-size: 20px;
+    vertical-align: top;
+    width: 14%;
+    height: 100px;
+  }
+  td.other {
+    color: gray;
+  }
+  td.today {
+    background-color: lightyellow;
+    font-style: italic;
+  }
+  span.day {
+    font-size: 25px;
+    font-weight: bold;
+  }
+  .error {
+    color: red;
+    font-size: 30px;
+  }
+  </style>
+</head>
+<body>
+  <h1>{{.Title}}</h1>
+  <p>
+    <a href="{{.PrevLink}}">&lt; {{.PrevTitle}}</a>
+    <a href="{{.ThisMonthLink}}">Today</a>
+    <a href="{{.NextLink}}">{{.NextTitle}} &gt;</a>
+    <a href="/year">Year</a>
+    <a href="/home">Home</a>
+  </p>
+  {{with .Error}}
+    <p class="error">{{.}}</p>
+  {{end}}
+  <table border=1 width="100%">
+    <tr>
+      {{range .Weekdays}}
+      <th>{{.}}</th>
+      {{end}}
+    </tr>
+    {{with $top := .}}
+    {{range .Weeks}}
+    <tr>
+      {{range .}}
+      <td class="{{$top.Class .}}">
+        <span class="day">{{.Date.Day}}</span>
+        {{range .Milestones}}
+        <br><a href="{{$top.PersonLink .}}">{{.EntryPtr.Name}}</a> {{.AgeString}}
+        {{end}}
+      </td>
+      {{end}}
+    </tr>
+    {{end}}
+    {{end}}
+  </table>
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+// Handler serves /calendar, a month grid with the milestones of each day
+// in its cell. The month parameter selects the month and defaults to the
+// current month. It can be YYYY-MM or any date that /home understands
+// such as "Dec" or "+1m". Handler honors the same q and p
+// parameters as /home.
+type Handler struct {
+	Store          birthday.Store
+	DefaultPeriods []birthday.Period
+	Mute           *mute.Rules
+	Clock          date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	today := birthday.Today(h.Clock)
+	first, err := parseMonth(h.Clock, r.Form.Get("month"))
+	var errorMessage string
+	if err != nil {
+		first = date_util.YMD(today.Year(), int(today.Month()), 1)
+		errorMessage = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	month := grid.NewMonth(first)
+	month.Add(h.Mute.Filter(common.Milestones(
+		entries,
+		common.ParsePeriods(r.Form.Get("p"), h.DefaultPeriods),
+		month.Start(),
+		month.End())))
+	nextMonth := first.AddDate(0, 1, 0)
+	prevMonth := first.AddDate(0, -1, 0)
+	http_util.WriteTemplate(w, kTemplate, &view{
+		Title:         first.Format("January 2006"),
+		PrevTitle:     prevMonth.Format("January"),
+		NextTitle:     nextMonth.Format("January"),
+		PrevLink:      monthLink(r.URL, prevMonth),
+		NextLink:      monthLink(r.URL, nextMonth),
+		ThisMonthLink: thisMonthLink(r.URL),
+		Weeks:         month.Weeks,
+		Error:         errorMessage,
+		today:         today,
+	})
+}
+
+// parseMonth returns the first day of the month monthStr names.
+// monthStr is YYYY-MM or anything dateparse.ParseDate understands.
+func parseMonth(clock date_util.Clock, monthStr string) (time.Time, error) {
+	if month, err := time.Parse("2006-01", monthStr); err == nil {
+		return month, nil
+	}
+	date, err := dateparse.ParseDate(clock, monthStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date_util.YMD(date.Year(), int(date.Month()), 1), nil
+}
+
+// monthLink returns u with its month parameter set to month.
+func monthLink(u *url.URL, month time.Time) *url.URL {
+	return http_util.WithParams(u, "month", month.Format("2006-01"))
+}
+
+// thisMonthLink returns u without its month parameter.
+func thisMonthLink(u *url.URL) *url.URL {
+	result := *u
+	values := result.Query()
+	values.Del("month")
+	result.RawQuery = values.Encode()
+	return &result
+}
+
+type view struct {
+	Title         string
+	PrevTitle     string
+	NextTitle     string
+	PrevLink      *url.URL
+	NextLink      *url.URL
+	ThisMonthLink *url.URL
+	Weeks         [][]*grid.Day
+	Error         string
+	today         time.Time
+}
+
+func (v *view) Weekdays() []string {
+	var result []string
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		result = append(result, weekday.String()[:3])
+	}
+	return result
+}
+
+func (v *view) Class(d *grid.Day) string {
+	switch {
+	case d.Date.Equal(v.today):
+		return "today"
+	case !d.InMonth:
+		return "other"
+	default:
+		return ""
+	}
+}
+
+func (v *view) PersonLink(milestone *birthday.Milestone) *url.URL {
+	return common.PersonLink(milestone.EntryPtr.Id)
+}
+
+func init() {
+	kTemplate = common.NewTemplate("calendar", kTemplateSpec)
+}
@@ -0,0 +1,117 @@
+// Package carddav serves the people in the birthday file as a read-only
+// CardDAV address book.
+package carddav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/cmd/remind/dav"
+	"github.com/keep94/birthday/vcard"
+)
+
+const (
+	kAddressBookName = "Birthdays"
+	kCollectionName  = "contacts/"
+	kContentType     = "text/vcard; charset=utf-8"
+)
+
+// Handler serves a read-only CardDAV address book with one vCard per
+// entry. Handler serves the principal and address book home at Prefix
+// and the one address book at Prefix + "contacts/". Each entry is a
+// resource named after its Id. Handler supports OPTIONS, PROPFIND,
+// REPORT addressbook-query, REPORT addressbook-multiget and GET.
+type Handler struct {
+	Store birthday.Store
+
+	// e.g "/carddav/"
+	Prefix string
+
+	// Used to make vCard UIDs globally unique
+	Domain string
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := &dav.Handler{
+		Prefix:         h.Prefix,
+		CollectionName: kCollectionName,
+		Compliance:     "1, 3, addressbook",
+		HomeProps: []dav.Property{
+			dav.Prop(dav.NSDAV, "resourcetype", "<D:collection/>"),
+			dav.Prop(dav.NSDAV, "displayname", dav.Escape(kAddressBookName)),
+			dav.Prop(dav.NSDAV, "current-user-principal", dav.Href(h.Prefix)),
+			dav.Prop(dav.NSDAV, "principal-URL", dav.Href(h.Prefix)),
+			dav.Prop(
+				dav.NSCardDAV, "addressbook-home-set", dav.Href(h.Prefix)),
+		},
+		Query: xml.Name{Space: dav.NSCardDAV, Local: "addressbook-query"},
+		Multiget: xml.Name{
+			Space: dav.NSCardDAV, Local: "addressbook-multiget"},
+		Data:        xml.Name{Space: dav.NSCardDAV, Local: "address-data"},
+		ContentType: kContentType,
+		Open:        h.open,
+	}
+	handler.ServeHTTP(w, r)
+}
+
+func (h *Handler) open() (dav.Collection, error) {
+	entries, err := common.ReadEntries(h.Store, "")
+	if err != nil {
+		return nil, err
+	}
+	result := &collection{
+		prefix: h.Prefix, byId: make(map[string]*dav.Resource, len(entries))}
+	for _, entry := range entries {
+		var buf bytes.Buffer
+		vcard.Write(&buf, entry, h.Domain)
+		resource := &dav.Resource{Name: entry.Id + ".vcf", Data: buf.String()}
+		result.resources = append(result.resources, resource)
+		result.byId[entry.Id] = resource
+	}
+	return result, nil
+}
+
+// collection is the address book as of a single request.
+type collection struct {
+	prefix    string
+	resources []*dav.Resource
+
+	// maps entry ids to resources
+	byId map[string]*dav.Resource
+}
+
+func (c *collection) Props() []dav.Property {
+	return []dav.Property{
+		dav.Prop(
+			dav.NSDAV, "resourcetype", "<D:collection/><CR:addressbook/>"),
+		dav.Prop(dav.NSDAV, "displayname", dav.Escape(kAddressBookName)),
+		dav.Prop(dav.NSDAV, "current-user-principal", dav.Href(c.prefix)),
+		dav.Prop(
+			dav.NSDAV,
+			"current-user-privilege-set",
+			"<D:privilege><D:read/></D:privilege>"),
+		dav.Prop(
+			dav.NSCardDAV,
+			"supported-address-data",
+			`<CR:address-data-type content-type="text/vcard" version="4.0"/>`),
+		dav.Prop(
+			dav.NSCalendarServer, "getctag", dav.Escape(dav.CTag(c.resources))),
+	}
+}
+
+func (c *collection) Resources(query *dav.Report) ([]*dav.Resource, error) {
+	return c.resources, nil
+}
+
+func (c *collection) Find(name string) (*dav.Resource, bool) {
+	id, ok := strings.CutSuffix(name, ".vcf")
+	if !ok {
+		return nil, false
+	}
+	resource, ok := c.byId[id]
+	return resource, ok
+}
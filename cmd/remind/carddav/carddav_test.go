@@ -0,0 +1,94 @@
+package carddav_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/carddav"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+var (
+	kEntries = []birthday.Entry{
+		{Name: "John Smith", Birthday: date_util.YMD(1967, 3, 25), Id: "john"},
+		{Name: "Merna", Birthday: date_util.YMD(0, 5, 17), Id: "merna"},
+	}
+)
+
+type entriesStore []birthday.Entry
+
+func (s entriesStore) Read(consumer consume2.Consumer[birthday.Entry]) error {
+	for _, entry := range s {
+		if !consumer.CanConsume() {
+			break
+		}
+		consumer.Consume(entry)
+	}
+	return nil
+}
+
+func newHandler() *carddav.Handler {
+	return &carddav.Handler{
+		Store:  entriesStore(kEntries),
+		Prefix: "/carddav/",
+		Domain: "example.com",
+	}
+}
+
+func TestAddressBookProps(t *testing.T) {
+	assert := asserts.New(t)
+	r := httptest.NewRequest("PROPFIND", "/carddav/", nil)
+	r.Header.Set("Depth", "1")
+	w := httptest.NewRecorder()
+	newHandler().ServeHTTP(w, r)
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	assert.Contains(
+		w.Body.String(),
+		"<CR:addressbook-home-set><D:href>/carddav/</D:href>")
+	assert.Contains(w.Body.String(), "<D:collection/><CR:addressbook/>")
+	assert.Contains(w.Body.String(), `content-type="text/vcard"`)
+}
+
+func TestAddressBookQuery(t *testing.T) {
+	assert := asserts.New(t)
+	w := httptest.NewRecorder()
+	newHandler().ServeHTTP(
+		w,
+		httptest.NewRequest(
+			"REPORT",
+			"/carddav/contacts/",
+			strings.NewReader(`<?xml version="1.0"?>
+<CR:addressbook-query xmlns:D="DAV:"
+    xmlns:CR="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/><CR:address-data/></D:prop>
+</CR:addressbook-query>`)))
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	body := w.Body.String()
+	assert.Contains(body, "<D:href>/carddav/contacts/john.vcf</D:href>")
+	assert.Contains(body, "<D:href>/carddav/contacts/merna.vcf</D:href>")
+	assert.Equal(2, strings.Count(body, "<CR:address-data>BEGIN:VCARD"))
+}
+
+func TestGetCard(t *testing.T) {
+	assert := asserts.New(t)
+	h := newHandler()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(
+		w,
+		httptest.NewRequest(
+			http.MethodGet, "/carddav/contacts/john.vcf", nil))
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("text/vcard; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(w.Body.String(), "FN:John Smith")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(
+		w,
+		httptest.NewRequest(http.MethodGet, "/carddav/contacts/john.ics", nil))
+	assert.Equal(http.StatusNotFound, w.Code)
+}
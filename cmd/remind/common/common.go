@@ -1,12 +1,22 @@
 package common
 
 import (
+	"fmt"
 	"html/template"
+	"iter"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/keep94/birthday"
-	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/consume2"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kPeriodLetters = "ymwdh"
 )
 
 // NewTemplate returns a new template instance. name is the name
@@ -15,24 +25,26 @@ func NewTemplate(name, templateStr string) *template.Template {
 	return template.Must(template.New(name).Parse(templateStr))
 }
 
-// ParseDate parses dateStr to a time in UTC.
-// dateStr can be of form mm/dd or mm/dd/yyyy. If dateStr is of form
-// mm/dd, then the current year is used as the year. If there is an error
-// parsing dateStr, then the ParseDate() returns the current date.
-func ParseDate(clock date_util.Clock, dateStr string) time.Time {
-	today := birthday.Today(clock)
-	result, err := birthday.Parse(dateStr)
+// ParseDays parses daysStr, the days parameter of a page. ParseDays
+// returns defaultDays if daysStr is empty or not a number.
+func ParseDays(daysStr string, defaultDays int) int {
+	result, err := strconv.Atoi(daysStr)
 	if err != nil {
-		return today
+		return defaultDays
 	}
-	return fixMissingYear(today, result)
+	return result
 }
 
-func fixMissingYear(today, date time.Time) time.Time {
-	if birthday.HasYear(date) {
-		return date
+// CheckPeriods returns an error if periodStr contains anything other
+// than the letters 'ymwdh'.
+func CheckPeriods(periodStr string) error {
+	if i := strings.IndexFunc(
+		periodStr,
+		func(r rune) bool { return !strings.ContainsRune(kPeriodLetters, r) },
+	); i != -1 {
+		return fmt.Errorf("invalid period letter: %q", periodStr[i:i+1])
 	}
-	return date_util.YMD(today.Year(), int(date.Month()), date.Day())
+	return nil
 }
 
 // ParsePeriods parses a periodStr of form 'ymwdh' into a slice of periods.
@@ -65,3 +77,64 @@ func ParsePeriods(
 	}
 	return result
 }
+
+// ReadEntries returns the entries in store matching query.
+func ReadEntries(store birthday.Store, query string) (
+	[]*birthday.Entry, error) {
+	var entries []*birthday.Entry
+	err := store.Read(
+		consume2.Filter(
+			consume2.AppendPtrsTo(&entries),
+			birthday.Query(query)))
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Milestones returns the milestones for entries and periods falling on
+// or after start and before end in chronological order.
+func Milestones(
+	entries []*birthday.Entry,
+	periods []birthday.Period,
+	start, end time.Time) iter.Seq[*birthday.Milestone] {
+	return itertools.TakeWhile(
+		func(m *birthday.Milestone) bool { return m.Date.Before(end) },
+		birthday.RemindPtrs(entries, periods, start))
+}
+
+// FindEntry returns the entry in store with the given id. If no such
+// entry exists, FindEntry returns false.
+func FindEntry(store birthday.Store, id string) (
+	entry birthday.Entry, ok bool, err error) {
+	var entries []birthday.Entry
+	err = store.Read(
+		consume2.Filter(
+			consume2.Slice(consume2.AppendTo(&entries), 0, 1),
+			func(e birthday.Entry) bool { return e.Id == id }))
+	if err != nil || len(entries) == 0 {
+		return
+	}
+	return entries[0], true, nil
+}
+
+// PersonLink returns the URL of the page for the person with given id.
+func PersonLink(id string) *url.URL {
+	return http_util.NewUrl("/person", "id", id)
+}
+
+// EditLink returns the URL of the page for editing the person with
+// given id.
+func EditLink(id string) *url.URL {
+	return http_util.NewUrl("/person/edit", "id", id)
+}
+
+// ReturnPath returns path if it is a path on this server and /home
+// otherwise so that forms can't redirect elsewhere.
+func ReturnPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") ||
+		strings.HasPrefix(path, "/\\") {
+		return "/home"
+	}
+	return path
+}
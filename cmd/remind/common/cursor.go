@@ -0,0 +1,202 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/itertools"
+)
+
+var (
+	errBadCursor = errors.New("invalid cursor")
+)
+
+// Cursor marks a position in a chronological sequence of milestones.
+// A Cursor encodes the date, entry and age of the last milestone seen.
+type Cursor struct {
+	Date       time.Time
+	EntryId    string
+	Age        birthday.Period
+	AgeUnknown bool
+}
+
+// NewCursor returns a Cursor positioned at milestone.
+func NewCursor(milestone *birthday.Milestone) *Cursor {
+	return &Cursor{
+		Date:       milestone.Date,
+		EntryId:    milestone.EntryPtr.Id,
+		Age:        milestone.Age,
+		AgeUnknown: milestone.AgeUnknown,
+	}
+}
+
+// ParseCursor parses a string produced by Cursor.String.
+func ParseCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errBadCursor
+	}
+	var cj cursorJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return nil, errBadCursor
+	}
+	date, err := time.Parse("2006-01-02", cj.Date)
+	if err != nil || cj.EntryId == "" {
+		return nil, errBadCursor
+	}
+	return &Cursor{
+		Date:    date,
+		EntryId: cj.EntryId,
+		Age: birthday.Period{
+			Years: cj.Years, Months: cj.Months, Weeks: cj.Weeks, Days: cj.Days},
+		AgeUnknown: cj.AgeUnknown,
+	}, nil
+}
+
+// ParseCursorParam is like ParseCursor except that it returns nil and
+// no error if s is empty.
+func ParseCursorParam(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return ParseCursor(s)
+}
+
+// String returns this cursor as an opaque string suitable for URLs.
+func (c *Cursor) String() string {
+	data, err := json.Marshal(&cursorJSON{
+		Date:       c.Date.Format("2006-01-02"),
+		EntryId:    c.EntryId,
+		Years:      c.Age.Years,
+		Months:     c.Age.Months,
+		Weeks:      c.Age.Weeks,
+		Days:       c.Age.Days,
+		AgeUnknown: c.AgeUnknown,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// After returns the milestones in seq that come after this cursor.
+// seq must be in chronological order and must start on or before the
+// cursor date. entries are used to look up the entry of this cursor.
+// If that entry no longer exists, After may repeat milestones on the
+// cursor date rather than skip ones not yet seen.
+func (c *Cursor) After(
+	entries []*birthday.Entry,
+	seq iter.Seq[*birthday.Milestone]) iter.Seq[*birthday.Milestone] {
+	marker := c.marker(entries)
+	return func(yield func(*birthday.Milestone) bool) {
+		for m := range seq {
+			if !marker.Less(m) {
+				continue
+			}
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+// Before returns the milestones in seq that come before this cursor.
+// seq must be in chronological order. entries are used to look up the
+// entry of this cursor.
+func (c *Cursor) Before(
+	entries []*birthday.Entry,
+	seq iter.Seq[*birthday.Milestone]) iter.Seq[*birthday.Milestone] {
+	marker := c.marker(entries)
+	return itertools.TakeWhile(
+		func(m *birthday.Milestone) bool { return m.Less(marker) }, seq)
+}
+
+func (c *Cursor) marker(entries []*birthday.Entry) *birthday.Milestone {
+	result := &birthday.Milestone{
+		EntryPtr:   &birthday.Entry{Id: c.EntryId},
+		Date:       c.Date,
+		Age:        c.Age,
+		AgeUnknown: c.AgeUnknown,
+	}
+	for _, entry := range entries {
+		if entry.Id == c.EntryId {
+			result.EntryPtr = entry
+			break
+		}
+	}
+	return result
+}
+
+// Page is one page of milestones.
+type Page struct {
+	Milestones []*birthday.Milestone
+
+	// Pass as the before parameter to get the previous page. nil if this
+	// is the first page.
+	Prev *Cursor
+
+	// Pass as the cursor parameter to get the next page. nil if this is
+	// the last page.
+	Next *Cursor
+}
+
+// Paginate returns a page of at most rows milestones from seq. seq must
+// be in chronological order and must start on or before the dates of
+// after and before. If after is non-nil, the page starts with the first
+// milestone after it. Otherwise, if before is non-nil, the page ends with
+// the last milestone before it. Otherwise the page starts at the
+// beginning of seq. entries are used to look up the entries of after
+// and before.
+func Paginate(
+	entries []*birthday.Entry,
+	seq iter.Seq[*birthday.Milestone],
+	after, before *Cursor,
+	rows int) *Page {
+	result := &Page{}
+	if after == nil && before != nil {
+		var milestones []*birthday.Milestone
+		for m := range before.Before(entries, seq) {
+			milestones = append(milestones, m)
+			if len(milestones) > rows+1 {
+				milestones = milestones[1:]
+			}
+		}
+		if len(milestones) > rows {
+			milestones = milestones[1:]
+			result.Prev = NewCursor(milestones[0])
+		}
+		result.Milestones = milestones
+		if len(milestones) > 0 {
+			result.Next = NewCursor(milestones[len(milestones)-1])
+		}
+		return result
+	}
+	if after != nil {
+		seq = after.After(entries, seq)
+	}
+	milestones := slices.Collect(itertools.Take(rows+1, seq))
+	if len(milestones) > rows {
+		milestones = milestones[:rows]
+		result.Next = NewCursor(milestones[rows-1])
+	}
+	result.Milestones = milestones
+	if after != nil && len(milestones) > 0 {
+		result.Prev = NewCursor(milestones[0])
+	}
+	return result
+}
+
+type cursorJSON struct {
+	Date       string `json:"d"`
+	EntryId    string `json:"e"`
+	Years      int    `json:"y,omitempty"`
+	Months     int    `json:"m,omitempty"`
+	Weeks      int    `json:"w,omitempty"`
+	Days       int    `json:"dd,omitempty"`
+	AgeUnknown bool   `json:"u,omitempty"`
+}
@@ -0,0 +1,219 @@
+package common
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/internal/milestonetext"
+)
+
+// Format is the format of a response.
+type Format int
+
+const (
+	HTML Format = iota
+	JSON
+	CSV
+	Text
+)
+
+var (
+	kFormatsByName = map[string]Format{
+		"html": HTML,
+		"json": JSON,
+		"csv":  CSV,
+		"text": Text,
+		"txt":  Text,
+	}
+	kFormatsByType = map[string]Format{
+		"text/html":        HTML,
+		"application/json": JSON,
+		"text/csv":         CSV,
+		"text/plain":       Text,
+	}
+)
+
+// ErrUnknownFormat means the format parameter names no known format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// NegotiateFormat returns the format r wants. The format parameter,
+// one of html, json, csv or text, takes precedence. Otherwise
+// NegotiateFormat picks the acceptable media type in the Accept header
+// with the highest quality. NegotiateFormat returns HTML if nothing else
+// matches so that browsers and clients that accept anything get HTML.
+func NegotiateFormat(r *http.Request) (Format, error) {
+	if name := r.FormValue("format"); name != "" {
+		format, ok := kFormatsByName[strings.ToLower(name)]
+		if !ok {
+			return HTML, ErrUnknownFormat
+		}
+		return format, nil
+	}
+	type choice struct {
+		format  Format
+		quality float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := kFormatsByType[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			choices = append(choices, choice{format: format, quality: quality})
+		}
+	}
+	if len(choices) == 0 {
+		return HTML, nil
+	}
+	sort.SliceStable(choices, func(i, j int) bool {
+		return choices[i].quality > choices[j].quality
+	})
+	return choices[0].format, nil
+}
+
+// MilestonesJSON is the JSON form of the milestones between Date and
+// EndDate.
+type MilestonesJSON struct {
+	Date       string           `json:"date"`
+	EndDate    string           `json:"endDate"`
+	Milestones []*MilestoneJSON `json:"milestones"`
+	PrevCursor string           `json:"prevCursor,omitempty"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// NewMilestonesJSON returns the JSON form of a page of milestones
+// between today and endDate.
+func NewMilestonesJSON(page *Page, today, endDate time.Time) *MilestonesJSON {
+	result := &MilestonesJSON{
+		Date:       ISODate(today),
+		EndDate:    ISODate(endDate),
+		Milestones: make([]*MilestoneJSON, 0, len(page.Milestones)),
+	}
+	for _, m := range page.Milestones {
+		result.Milestones = append(result.Milestones, NewMilestoneJSON(m))
+	}
+	if page.Prev != nil {
+		result.PrevCursor = page.Prev.String()
+	}
+	if page.Next != nil {
+		result.NextCursor = page.Next.String()
+	}
+	return result
+}
+
+// EntriesJSON is the JSON form of entries with ages as of Date.
+type EntriesJSON struct {
+	Date    string       `json:"date"`
+	Entries []*EntryJSON `json:"entries"`
+}
+
+// NewEntriesJSON returns the JSON form of entries with ages as of today.
+func NewEntriesJSON(entries []*birthday.Entry, today time.Time) *EntriesJSON {
+	result := &EntriesJSON{
+		Date:    ISODate(today),
+		Entries: make([]*EntryJSON, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, NewEntryJSONWithAges(entry, today))
+	}
+	return result
+}
+
+// WriteMilestones writes a page of milestones between today and endDate
+// to w as JSON, CSV or plain text. WriteMilestones returns false if
+// format is HTML, which callers render themselves.
+func WriteMilestones(
+	w http.ResponseWriter,
+	format Format,
+	page *Page,
+	today, endDate time.Time) bool {
+	switch format {
+	case JSON:
+		WriteJSON(w, http.StatusOK, NewMilestonesJSON(page, today, endDate))
+	case CSV:
+		setContentType(w, "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"date", "weekday", "id", "name", "age"})
+		for _, m := range page.Milestones {
+			writer.Write([]string{
+				ISODate(m.Date),
+				m.Date.Weekday().String(),
+				m.EntryPtr.Id,
+				m.EntryPtr.Name,
+				m.AgeString(),
+			})
+		}
+		writer.Flush()
+	case Text:
+		setContentType(w, "text/plain")
+		for _, m := range page.Milestones {
+			milestonetext.Write(w, m, today)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// WriteEntries writes entries with ages as of today to w as JSON, CSV or
+// plain text. WriteEntries returns false if format is HTML, which
+// callers render themselves.
+func WriteEntries(
+	w http.ResponseWriter,
+	format Format,
+	entries []*birthday.Entry,
+	today time.Time) bool {
+	switch format {
+	case JSON:
+		WriteJSON(w, http.StatusOK, NewEntriesJSON(entries, today))
+	case CSV:
+		setContentType(w, "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write([]string{
+			"id", "name", "birthday", "years", "months", "weeks", "days"})
+		for _, entry := range entries {
+			record := []string{
+				entry.Id, entry.Name, ISODate(entry.Birthday), "", "", "", ""}
+			if ages := NewEntryJSONWithAges(entry, today).Ages; ages != nil {
+				record[3] = strconv.Itoa(ages.Years)
+				record[4] = strconv.Itoa(ages.Months)
+				record[5] = strconv.Itoa(ages.Weeks)
+				record[6] = strconv.Itoa(ages.Days)
+			}
+			writer.Write(record)
+		}
+		writer.Flush()
+	case Text:
+		setContentType(w, "text/plain")
+		for _, entry := range entries {
+			fmt.Fprintf(
+				w, "%10s %s\n", birthday.ToString(entry.Birthday), entry.Name)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func setContentType(w http.ResponseWriter, mediaType string) {
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+}
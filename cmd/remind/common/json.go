@@ -0,0 +1,130 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/keep94/birthday"
+)
+
+var (
+	kYears  = birthday.Period{Years: 1}
+	kMonths = birthday.Period{Months: 1}
+	kWeeks  = birthday.Period{Weeks: 1}
+	kDays   = birthday.Period{Days: 1}
+)
+
+// PeriodJSON is the JSON form of a birthday.Period.
+type PeriodJSON struct {
+	Years  int `json:"years,omitempty"`
+	Months int `json:"months,omitempty"`
+	Weeks  int `json:"weeks,omitempty"`
+	Days   int `json:"days,omitempty"`
+}
+
+// NewPeriodJSON returns the JSON form of p.
+func NewPeriodJSON(p birthday.Period) *PeriodJSON {
+	return &PeriodJSON{
+		Years:  p.Years,
+		Months: p.Months,
+		Weeks:  p.Weeks,
+		Days:   p.Days,
+	}
+}
+
+// AgesJSON is the age of a person in each unit of time.
+type AgesJSON struct {
+	Years  int `json:"years"`
+	Months int `json:"months"`
+	Weeks  int `json:"weeks"`
+	Days   int `json:"days"`
+}
+
+// EntryJSON is the JSON form of a birthday.Entry.
+type EntryJSON struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// Birthday as YYYY-MM-DD or as --MM-DD if the year is unknown.
+	Birthday string `json:"birthday"`
+
+	// Age as of a particular date. nil if the year of birth is unknown
+	// or if the age is not wanted.
+	Ages *AgesJSON `json:"ages,omitempty"`
+}
+
+// NewEntryJSON returns the JSON form of entry without ages.
+func NewEntryJSON(entry *birthday.Entry) *EntryJSON {
+	return &EntryJSON{
+		Id:       entry.Id,
+		Name:     entry.Name,
+		Birthday: ISODate(entry.Birthday),
+	}
+}
+
+// NewEntryJSONWithAges returns the JSON form of entry including the age
+// as of current.
+func NewEntryJSONWithAges(
+	entry *birthday.Entry, current time.Time) *EntryJSON {
+	result := NewEntryJSON(entry)
+	if birthday.HasYear(entry.Birthday) {
+		result.Ages = &AgesJSON{
+			Years:  kYears.Diff(current, entry.Birthday),
+			Months: kMonths.Diff(current, entry.Birthday),
+			Weeks:  kWeeks.Diff(current, entry.Birthday),
+			Days:   kDays.Diff(current, entry.Birthday),
+		}
+	}
+	return result
+}
+
+// MilestoneJSON is the JSON form of a birthday.Milestone.
+type MilestoneJSON struct {
+	Date      string      `json:"date"`
+	Weekday   string      `json:"weekday"`
+	Entry     *EntryJSON  `json:"entry"`
+	Age       *PeriodJSON `json:"age"`
+	AgeString string      `json:"ageString"`
+}
+
+// NewMilestoneJSON returns the JSON form of milestone.
+func NewMilestoneJSON(milestone *birthday.Milestone) *MilestoneJSON {
+	result := &MilestoneJSON{
+		Date:      ISODate(milestone.Date),
+		Weekday:   milestone.Date.Weekday().String(),
+		Entry:     NewEntryJSON(milestone.EntryPtr),
+		AgeString: milestone.AgeString(),
+	}
+	if !milestone.AgeUnknown {
+		result.Age = NewPeriodJSON(milestone.Age)
+	}
+	return result
+}
+
+// ISODate returns t as YYYY-MM-DD or as --MM-DD if t has no year.
+func ISODate(t time.Time) string {
+	if !birthday.HasYear(t) {
+		return t.Format("--01-02")
+	}
+	return t.Format("2006-01-02")
+}
+
+// WriteJSON writes v as JSON to w with the given HTTP status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// WriteJSONError writes a JSON error message to w with the given HTTP
+// status.
+func WriteJSONError(w http.ResponseWriter, status int, message string) {
+	WriteJSON(w, status, &errorJSON{Error: message})
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
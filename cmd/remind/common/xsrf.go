@@ -0,0 +1,59 @@
+package common
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Xsrf creates and verifies xsrf tokens for forms.
+type Xsrf struct {
+	secret []byte
+}
+
+// NewXsrf returns a new Xsrf instance with a random secret. Tokens
+// do not survive a restart of the server.
+func NewXsrf() *Xsrf {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return &Xsrf{secret: secret}
+}
+
+// NewToken creates a new xsrf token.
+// action identifies the web page; expire is when the token expires.
+func (x *Xsrf) NewToken(action string, expire time.Time) string {
+	expireUnix := expire.Unix()
+	return fmt.Sprintf("%d:%s", expireUnix, x.checksum(expireUnix, action))
+}
+
+// VerifyToken returns true if token is valid or false otherwise.
+// action identifies the web page; now is the current time.
+func (x *Xsrf) VerifyToken(token, action string, now time.Time) bool {
+	idx := strings.IndexByte(token, ':')
+	if idx == -1 {
+		return false
+	}
+	expireUnix, err := strconv.ParseInt(token[:idx], 10, 64)
+	if err != nil {
+		return false
+	}
+	if now.Unix() >= expireUnix {
+		return false
+	}
+	return hmac.Equal(
+		[]byte(token[idx+1:]), []byte(x.checksum(expireUnix, action)))
+}
+
+func (x *Xsrf) checksum(expireUnix int64, action string) string {
+	mac := hmac.New(sha256.New, x.secret)
+	fmt.Fprintf(mac, "%d_%s", expireUnix, action)
+	return strings.TrimRight(
+		base32.StdEncoding.EncodeToString(mac.Sum(nil)), "=")
+}
@@ -0,0 +1,318 @@
+// Package dav contains the WebDAV plumbing shared by the read-only
+// CalDAV and CardDAV handlers of the remind server.
+package dav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Namespaces used by the CalDAV and CardDAV handlers.
+const (
+	NSDAV            = "DAV:"
+	NSCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	NSCardDAV        = "urn:ietf:params:xml:ns:carddav"
+	NSCalendarServer = "http://calendarserver.org/ns/"
+)
+
+var (
+	kPrefixes = map[string]string{
+		NSDAV:            "D",
+		NSCalDAV:         "C",
+		NSCardDAV:        "CR",
+		NSCalendarServer: "CS",
+	}
+)
+
+// Property is a single WebDAV property.
+type Property struct {
+	Name xml.Name
+
+	// The value of the property as XML. Use Escape for text values.
+	// Elements must use the prefixes D, C, CR and CS for the DAV,
+	// CalDAV, CardDAV and calendar server namespaces.
+	InnerXML string
+}
+
+// Prop returns a Property in namespace space.
+func Prop(space, local, innerXML string) Property {
+	return Property{Name: xml.Name{Space: space, Local: local}, InnerXML: innerXML}
+}
+
+// Href returns href as the XML of a DAV:href element.
+func Href(href string) string {
+	return "<D:href>" + Escape(href) + "</D:href>"
+}
+
+// Escape escapes s for use as XML text.
+func Escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// Response is a single response of a multistatus.
+type Response struct {
+	Href string
+
+	// The properties found
+	Props []Property
+
+	// The properties requested but not found
+	NotFound []xml.Name
+
+	// If true, the resource itself was not found.
+	Missing bool
+}
+
+// PropFind is a parsed PROPFIND request or a list of properties
+// requested in a REPORT.
+type PropFind struct {
+
+	// If true, all properties are wanted.
+	AllProp bool
+
+	// If true, only the names of all properties are wanted.
+	PropName bool
+
+	// The properties wanted if AllProp is false.
+	Props []xml.Name
+}
+
+// ParsePropFind parses the body of a PROPFIND request. An empty body
+// means all properties.
+func ParsePropFind(r io.Reader) (*PropFind, error) {
+	decoder := xml.NewDecoder(r)
+	result := &PropFind{}
+	inProp := 0
+	sawRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			sawRoot = true
+			if inProp > 0 {
+				if inProp == 1 {
+					result.Props = append(result.Props, t.Name)
+				}
+				inProp++
+				continue
+			}
+			if t.Name.Space == NSDAV {
+				switch t.Name.Local {
+				case "allprop":
+					result.AllProp = true
+				case "propname":
+					result.PropName = true
+				case "prop":
+					inProp = 1
+				}
+			}
+		case xml.EndElement:
+			if inProp > 0 {
+				inProp--
+			}
+		}
+	}
+	if !sawRoot {
+		result.AllProp = true
+	}
+	return result, nil
+}
+
+// Select splits props into the ones this PropFind wants and the names of
+// wanted properties missing from props. For a propname request, found
+// has all of props without their values.
+func (p *PropFind) Select(props []Property) (
+	found []Property, notFound []xml.Name) {
+	if p.PropName {
+		for _, prop := range props {
+			found = append(found, Property{Name: prop.Name})
+		}
+		return found, nil
+	}
+	if p.AllProp {
+		return props, nil
+	}
+	for _, name := range p.Props {
+		idx := -1
+		for i := range props {
+			if props[i].Name == name {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			notFound = append(notFound, name)
+		} else {
+			found = append(found, props[idx])
+		}
+	}
+	return
+}
+
+// Wants returns true if this PropFind wants the value of the named
+// property.
+func (p *PropFind) Wants(space, local string) bool {
+	if p.AllProp {
+		return true
+	}
+	for _, name := range p.Props {
+		if name.Space == space && name.Local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// Depth returns the value of the Depth header as 0 or 1. Infinity is
+// treated as 1. A missing header defaults to 0.
+func Depth(r *http.Request) int {
+	switch strings.ToLower(r.Header.Get("Depth")) {
+	case "1", "infinity":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// WriteMultistatus writes responses as a 207 multistatus.
+func WriteMultistatus(w http.ResponseWriter, responses []*Response) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	buf.WriteString(`<D:multistatus`)
+	for _, ns := range []string{NSDAV, NSCalDAV, NSCardDAV, NSCalendarServer} {
+		fmt.Fprintf(&buf, ` xmlns:%s="%s"`, kPrefixes[ns], ns)
+	}
+	buf.WriteString(">\n")
+	for _, response := range responses {
+		buf.WriteString("<D:response>")
+		buf.WriteString(Href(response.Href))
+		if response.Missing {
+			buf.WriteString("<D:status>HTTP/1.1 404 Not Found</D:status>")
+		}
+		if len(response.Props) > 0 {
+			buf.WriteString("<D:propstat><D:prop>")
+			for _, prop := range response.Props {
+				writeElement(&buf, prop.Name, prop.InnerXML)
+			}
+			buf.WriteString("</D:prop>")
+			buf.WriteString("<D:status>HTTP/1.1 200 OK</D:status></D:propstat>")
+		}
+		if len(response.NotFound) > 0 {
+			buf.WriteString("<D:propstat><D:prop>")
+			for _, name := range response.NotFound {
+				writeElement(&buf, name, "")
+			}
+			buf.WriteString("</D:prop>")
+			buf.WriteString(
+				"<D:status>HTTP/1.1 404 Not Found</D:status></D:propstat>")
+		}
+		buf.WriteString("</D:response>\n")
+	}
+	buf.WriteString("</D:multistatus>\n")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(buf.Bytes())
+}
+
+func writeElement(buf *bytes.Buffer, name xml.Name, innerXML string) {
+	tag, xmlns := name.Local, ""
+	if prefix, ok := kPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "X:" + name.Local
+		xmlns = fmt.Sprintf(` xmlns:X="%s"`, Escape(name.Space))
+	}
+	if innerXML == "" {
+		fmt.Fprintf(buf, "<%s%s/>", tag, xmlns)
+		return
+	}
+	fmt.Fprintf(buf, "<%s%s>%s</%s>", tag, xmlns, innerXML, tag)
+}
+
+// Report is a parsed REPORT request.
+type Report struct {
+
+	// The name of the root element e.g calendar-query
+	Name xml.Name
+
+	// The properties wanted
+	PropFind *PropFind
+
+	// The hrefs of a multiget report
+	Hrefs []string
+
+	// The attributes of the first time-range element, if any
+	TimeRange map[string]string
+}
+
+// ParseReport parses the body of a REPORT request.
+func ParseReport(r io.Reader) (*Report, error) {
+	decoder := xml.NewDecoder(r)
+	result := &Report{PropFind: &PropFind{}}
+	depth, inProp := 0, 0
+	inHref := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				result.Name = t.Name
+				continue
+			}
+			if inProp > 0 {
+				if inProp == 1 {
+					result.PropFind.Props = append(result.PropFind.Props, t.Name)
+				}
+				inProp++
+				continue
+			}
+			switch {
+			case t.Name.Space == NSDAV && t.Name.Local == "prop" && depth == 2:
+				inProp = 1
+			case t.Name.Space == NSDAV && t.Name.Local == "allprop":
+				result.PropFind.AllProp = true
+			case t.Name.Space == NSDAV && t.Name.Local == "href":
+				inHref = true
+			case t.Name.Local == "time-range" && result.TimeRange == nil:
+				result.TimeRange = make(map[string]string)
+				for _, attr := range t.Attr {
+					result.TimeRange[attr.Name.Local] = attr.Value
+				}
+			}
+		case xml.EndElement:
+			depth--
+			if inProp > 0 {
+				inProp--
+			}
+			inHref = false
+		case xml.CharData:
+			if inHref {
+				result.Hrefs = append(
+					result.Hrefs, strings.TrimSpace(string(t)))
+			}
+		}
+	}
+	if result.Name.Local == "" {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return result, nil
+}
@@ -0,0 +1,340 @@
+package dav_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/keep94/birthday/cmd/remind/dav"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+var (
+	kProps = []dav.Property{
+		dav.Prop(dav.NSDAV, "displayname", "Birthdays"),
+		dav.Prop(dav.NSDAV, "getetag", `"abc"`),
+	}
+
+	kHref = regexp.MustCompile(`<D:response><D:href>([^<]*)</D:href>`)
+)
+
+// fakeCollection serves resources and counts how often it is opened.
+type fakeCollection struct {
+	resources []*dav.Resource
+	opens     int
+}
+
+func (c *fakeCollection) open() (dav.Collection, error) {
+	c.opens++
+	return c, nil
+}
+
+func (c *fakeCollection) Props() []dav.Property {
+	return []dav.Property{dav.Prop(dav.NSDAV, "displayname", "People")}
+}
+
+func (c *fakeCollection) Resources(query *dav.Report) ([]*dav.Resource, error) {
+	if query != nil && query.TimeRange != nil {
+		return nil, dav.ErrBadQuery
+	}
+	return c.resources, nil
+}
+
+func (c *fakeCollection) Find(name string) (*dav.Resource, bool) {
+	for _, resource := range c.resources {
+		if resource.Name == name {
+			return resource, true
+		}
+	}
+	return nil, false
+}
+
+func newHandler() (*dav.Handler, *fakeCollection) {
+	collection := &fakeCollection{
+		resources: []*dav.Resource{
+			{Name: "ann.vcf", Data: "BEGIN:VCARD\r\nFN:Ann\r\nEND:VCARD\r\n"},
+			{Name: "bob jr.vcf", Data: "BEGIN:VCARD\r\nFN:Bob\r\nEND:VCARD\r\n"},
+		},
+	}
+	return &dav.Handler{
+		Prefix:         "/carddav/",
+		CollectionName: "contacts/",
+		Compliance:     "1, 3, addressbook",
+		HomeProps: []dav.Property{
+			dav.Prop(dav.NSDAV, "resourcetype", "<D:collection/>"),
+			dav.Prop(
+				dav.NSCardDAV, "addressbook-home-set", dav.Href("/carddav/")),
+		},
+		Query: xml.Name{Space: dav.NSCardDAV, Local: "addressbook-query"},
+		Multiget: xml.Name{
+			Space: dav.NSCardDAV, Local: "addressbook-multiget"},
+		Data:        xml.Name{Space: dav.NSCardDAV, Local: "address-data"},
+		ContentType: "text/vcard; charset=utf-8",
+		Open:        collection.open,
+	}, collection
+}
+
+func serve(
+	h http.Handler,
+	method, path, depth, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if depth != "" {
+		r.Header.Set("Depth", depth)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func hrefs(body string) []string {
+	var result []string
+	for _, match := range kHref.FindAllStringSubmatch(body, -1) {
+		result = append(result, match[1])
+	}
+	return result
+}
+
+func parsePropFind(t *testing.T, body string) *dav.PropFind {
+	result, err := dav.ParsePropFind(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestPropFindProp(t *testing.T) {
+	assert := asserts.New(t)
+	propFind := parsePropFind(t, `<?xml version="1.0"?>
+<D:propfind xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:displayname/><C:calendar-data/></D:prop>
+</D:propfind>`)
+	assert.True(propFind.Wants(dav.NSDAV, "displayname"))
+	assert.False(propFind.Wants(dav.NSDAV, "getetag"))
+	found, notFound := propFind.Select(kProps)
+	assert.Equal(kProps[:1], found)
+	assert.Equal(
+		[]xml.Name{{Space: dav.NSCalDAV, Local: "calendar-data"}}, notFound)
+}
+
+func TestPropFindAllProp(t *testing.T) {
+	assert := asserts.New(t)
+	for _, body := range []string{
+		"", `<D:propfind xmlns:D="DAV:"><D:allprop/></D:propfind>`} {
+		propFind := parsePropFind(t, body)
+		assert.True(propFind.Wants(dav.NSDAV, "getetag"))
+		found, notFound := propFind.Select(kProps)
+		assert.Equal(kProps, found)
+		assert.Empty(notFound)
+	}
+}
+
+func TestPropFindPropName(t *testing.T) {
+	assert := asserts.New(t)
+	propFind := parsePropFind(
+		t, `<D:propfind xmlns:D="DAV:"><D:propname/></D:propfind>`)
+	assert.False(propFind.Wants(dav.NSDAV, "getetag"))
+	found, notFound := propFind.Select(kProps)
+	assert.Equal(
+		[]dav.Property{
+			{Name: xml.Name{Space: dav.NSDAV, Local: "displayname"}},
+			{Name: xml.Name{Space: dav.NSDAV, Local: "getetag"}},
+		},
+		found)
+	assert.Empty(notFound)
+	w := httptest.NewRecorder()
+	dav.WriteMultistatus(w, []*dav.Response{{Href: "/a", Props: found}})
+	assert.Equal(207, w.Code)
+	assert.Contains(w.Body.String(), "<D:displayname/><D:getetag/>")
+	assert.NotContains(w.Body.String(), "Birthdays")
+}
+
+func TestParseReport(t *testing.T) {
+	assert := asserts.New(t)
+	report, err := dav.ParseReport(strings.NewReader(`<?xml version="1.0"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">
+        <C:time-range start="20240301T000000Z" end="20240401T000000Z"/>
+      </C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>`))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(
+		xml.Name{Space: dav.NSCalDAV, Local: "calendar-query"}, report.Name)
+	assert.True(report.PropFind.Wants(dav.NSDAV, "getetag"))
+	assert.Equal(
+		map[string]string{
+			"start": "20240301T000000Z", "end": "20240401T000000Z"},
+		report.TimeRange)
+
+	report, err = dav.ParseReport(strings.NewReader(`<?xml version="1.0"?>
+<C:calendar-multiget xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+  <D:href> /caldav/birthdays/a.ics </D:href>
+  <D:href>/caldav/birthdays/b.ics</D:href>
+</C:calendar-multiget>`))
+	if assert.NoError(err) {
+		assert.Equal(
+			[]string{"/caldav/birthdays/a.ics", "/caldav/birthdays/b.ics"},
+			report.Hrefs)
+	}
+
+	_, err = dav.ParseReport(strings.NewReader(""))
+	assert.Error(err)
+}
+
+func TestDepth(t *testing.T) {
+	assert := asserts.New(t)
+	r := httptest.NewRequest("PROPFIND", "/", nil)
+	assert.Equal(0, dav.Depth(r))
+	r.Header.Set("Depth", "1")
+	assert.Equal(1, dav.Depth(r))
+	r.Header.Set("Depth", "infinity")
+	assert.Equal(1, dav.Depth(r))
+	r.Header.Set("Depth", "0")
+	assert.Equal(0, dav.Depth(r))
+}
+
+func TestHandlerOptions(t *testing.T) {
+	assert := asserts.New(t)
+	h, _ := newHandler()
+	w := serve(h, http.MethodOptions, "/carddav/", "", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("1, 3, addressbook", w.Header().Get("DAV"))
+	w = serve(h, http.MethodPut, "/carddav/contacts/ann.vcf", "", "")
+	assert.Equal(http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandlerPropFind(t *testing.T) {
+	assert := asserts.New(t)
+	h, collection := newHandler()
+	w := serve(h, "PROPFIND", "/carddav/", "0", "")
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	assert.Equal([]string{"/carddav/"}, hrefs(w.Body.String()))
+	assert.Contains(w.Body.String(), "addressbook-home-set")
+	assert.Zero(collection.opens)
+
+	w = serve(h, "PROPFIND", "/carddav/", "1", "")
+	assert.Equal(
+		[]string{"/carddav/", "/carddav/contacts/"}, hrefs(w.Body.String()))
+	assert.Contains(w.Body.String(), "People")
+
+	w = serve(h, "PROPFIND", "/carddav/contacts/", "0", "")
+	assert.Equal([]string{"/carddav/contacts/"}, hrefs(w.Body.String()))
+
+	w = serve(h, "PROPFIND", "/carddav/contacts/", "1", "")
+	assert.Equal(
+		[]string{
+			"/carddav/contacts/",
+			"/carddav/contacts/ann.vcf",
+			"/carddav/contacts/bob%20jr.vcf",
+		},
+		hrefs(w.Body.String()))
+	assert.Contains(
+		w.Body.String(),
+		"<D:getcontenttype>text/vcard; charset=utf-8</D:getcontenttype>")
+	assert.NotContains(w.Body.String(), "BEGIN:VCARD")
+
+	w = serve(h, "PROPFIND", "/carddav/contacts/bob%20jr.vcf", "0", "")
+	assert.Equal(
+		[]string{"/carddav/contacts/bob%20jr.vcf"}, hrefs(w.Body.String()))
+
+	w = serve(h, "PROPFIND", "/carddav/contacts/nobody.vcf", "0", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestHandlerQuery(t *testing.T) {
+	assert := asserts.New(t)
+	h, _ := newHandler()
+	w := serve(h, "REPORT", "/carddav/contacts/", "1", `<?xml version="1.0"?>
+<CR:addressbook-query xmlns:D="DAV:"
+    xmlns:CR="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/><CR:address-data/></D:prop>
+</CR:addressbook-query>`)
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	assert.Len(hrefs(w.Body.String()), 2)
+	assert.Contains(w.Body.String(), "FN:Ann")
+	assert.Contains(w.Body.String(), "FN:Bob")
+
+	w = serve(h, "REPORT", "/carddav/contacts/", "1", `<?xml version="1.0"?>
+<CR:addressbook-query xmlns:D="DAV:"
+    xmlns:CR="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/></D:prop>
+  <CR:filter><CR:time-range start="x"/></CR:filter>
+</CR:addressbook-query>`)
+	assert.Equal(http.StatusBadRequest, w.Code)
+
+	w = serve(h, "REPORT", "/carddav/contacts/", "1", `<?xml version="1.0"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/></D:prop>
+</C:calendar-query>`)
+	assert.Equal(http.StatusForbidden, w.Code)
+}
+
+func TestHandlerMultiget(t *testing.T) {
+	assert := asserts.New(t)
+	h, collection := newHandler()
+	missing := "/carddav/contacts/nobody.vcf"
+	w := serve(h, "REPORT", "/carddav/contacts/", "1", `<?xml version="1.0"?>
+<CR:addressbook-multiget xmlns:D="DAV:"
+    xmlns:CR="urn:ietf:params:xml:ns:carddav">
+  <D:prop><D:getetag/><CR:address-data/></D:prop>
+  <D:href>/carddav/contacts/ann.vcf</D:href>
+  <D:href>http://example.com/carddav/contacts/bob%20jr.vcf</D:href>
+  <D:href>`+missing+`</D:href>
+</CR:addressbook-multiget>`)
+	assert.Equal(http.StatusMultiStatus, w.Code)
+	body := w.Body.String()
+	assert.Contains(body, "FN:Ann")
+	assert.Contains(body, "FN:Bob")
+	assert.Contains(
+		body,
+		"<D:href>"+missing+"</D:href>"+
+			"<D:status>HTTP/1.1 404 Not Found</D:status>")
+	assert.Equal(1, collection.opens)
+}
+
+func TestHandlerGet(t *testing.T) {
+	assert := asserts.New(t)
+	h, collection := newHandler()
+	w := serve(h, http.MethodGet, "/carddav/contacts/ann.vcf", "", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("text/vcard; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(collection.resources[0].ETag(), w.Header().Get("ETag"))
+	assert.Equal(collection.resources[0].Data, w.Body.String())
+
+	w = serve(h, http.MethodGet, "/carddav/contacts/nobody.vcf", "", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+	w = serve(h, http.MethodGet, "/carddav/", "", "")
+	assert.Equal(http.StatusNotFound, w.Code)
+}
+
+func TestHandlerOpenError(t *testing.T) {
+	assert := asserts.New(t)
+	h, _ := newHandler()
+	h.Open = func() (dav.Collection, error) {
+		return nil, errors.New("disk on fire")
+	}
+	w := serve(h, "PROPFIND", "/carddav/contacts/", "1", "")
+	assert.Equal(http.StatusInternalServerError, w.Code)
+}
+
+func TestETagAndCTag(t *testing.T) {
+	assert := asserts.New(t)
+	a := &dav.Resource{Name: "a", Data: "one"}
+	b := &dav.Resource{Name: "b", Data: "two"}
+	assert.NotEqual(a.ETag(), b.ETag())
+	assert.Equal(a.ETag(), (&dav.Resource{Name: "c", Data: "one"}).ETag())
+	ctag := dav.CTag([]*dav.Resource{a, b})
+	b.Data = "three"
+	assert.NotEqual(ctag, dav.CTag([]*dav.Resource{a, b}))
+}
@@ -0,0 +1,288 @@
+package dav
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kAllow = "OPTIONS, GET, HEAD, PROPFIND, REPORT"
+)
+
+var (
+	// ErrBadQuery is returned by Collection.Resources when it can't make
+	// sense of a query report. Handler answers such reports with 400.
+	ErrBadQuery = errors.New("dav: bad query")
+)
+
+// Resource is a single resource in a Collection.
+type Resource struct {
+
+	// The last segment of the path of the resource e.g "john.vcf"
+	Name string
+
+	// The contents of the resource e.g a vCard
+	Data string
+}
+
+// ETag returns the ETag of r which is derived from its contents.
+func (r *Resource) ETag() string {
+	sum := sha256.Sum256([]byte(r.Data))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// CTag returns a tag that changes whenever any of resources change.
+func CTag(resources []*Resource) string {
+	hash := sha256.New()
+	for _, resource := range resources {
+		hash.Write([]byte(resource.ETag()))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+// Collection is the one collection a Handler serves as it is at the
+// time of a single request.
+type Collection interface {
+
+	// Props returns the properties of the collection itself.
+	Props() []Property
+
+	// Resources returns the resources in the collection. query is the
+	// query report asking for them or nil for a PROPFIND.
+	Resources(query *Report) ([]*Resource, error)
+
+	// Find returns the resource with the given name. If there is no such
+	// resource, Find returns false.
+	Find(name string) (*Resource, bool)
+}
+
+// Handler serves a read-only WebDAV collection. Handler serves the
+// principal and collection home at Prefix and the collection at
+// Prefix + CollectionName. Each resource of the collection is at the
+// collection path plus the name of the resource. Handler supports
+// OPTIONS, PROPFIND, the query and multiget REPORTs and GET of
+// resources.
+type Handler struct {
+
+	// e.g "/caldav/"
+	Prefix string
+
+	// e.g "birthdays/"
+	CollectionName string
+
+	// The value of the DAV header e.g "1, 3, calendar-access"
+	Compliance string
+
+	// The properties of Prefix
+	HomeProps []Property
+
+	// The root element of query reports e.g calendar-query
+	Query xml.Name
+
+	// The root element of multiget reports e.g calendar-multiget
+	Multiget xml.Name
+
+	// The property holding the contents of a resource e.g calendar-data
+	Data xml.Name
+
+	// The content type of resources
+	ContentType string
+
+	// Open reads the collection. Handler calls Open at most once per
+	// request.
+	Open func() (Collection, error)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("DAV", h.Compliance)
+		w.Header().Set("Allow", kAllow)
+	case "PROPFIND":
+		h.propFind(w, r)
+	case "REPORT":
+		h.report(w, r)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r)
+	default:
+		w.Header().Set("Allow", kAllow)
+		http_util.Error(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// CollectionPath returns the path of the collection.
+func (h *Handler) CollectionPath() string {
+	return h.Prefix + h.CollectionName
+}
+
+func (h *Handler) propFind(w http.ResponseWriter, r *http.Request) {
+	propFind, err := ParsePropFind(r.Body)
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	path := r.URL.Path
+	depth := Depth(r)
+	var responses []*Response
+	if path == h.Prefix {
+		responses = append(responses, newResponse(propFind, path, h.HomeProps))
+		if depth == 0 {
+			WriteMultistatus(w, responses)
+			return
+		}
+	}
+	collection, err := h.Open()
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	switch path {
+	case h.Prefix:
+		responses = append(
+			responses,
+			newResponse(propFind, h.CollectionPath(), collection.Props()))
+	case h.CollectionPath():
+		responses = append(
+			responses, newResponse(propFind, path, collection.Props()))
+		if depth > 0 {
+			resources, err := collection.Resources(nil)
+			if err != nil {
+				http_util.ReportError(w, "Error reading birthday file", err)
+				return
+			}
+			for _, resource := range resources {
+				responses = append(
+					responses,
+					newResponse(
+						propFind,
+						h.href(resource),
+						h.resourceProps(resource, false)))
+			}
+		}
+	default:
+		resource, ok := h.find(collection, path)
+		if !ok {
+			http_util.Error(w, http.StatusNotFound)
+			return
+		}
+		responses = append(
+			responses,
+			newResponse(
+				propFind, h.href(resource), h.resourceProps(resource, false)))
+	}
+	WriteMultistatus(w, responses)
+}
+
+func (h *Handler) report(w http.ResponseWriter, r *http.Request) {
+	report, err := ParseReport(r.Body)
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	if report.Name != h.Query && report.Name != h.Multiget {
+		http_util.Error(w, http.StatusForbidden)
+		return
+	}
+	collection, err := h.Open()
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	withData := report.PropFind.Wants(h.Data.Space, h.Data.Local)
+	var responses []*Response
+	if report.Name == h.Query {
+		resources, err := collection.Resources(report)
+		if err == ErrBadQuery {
+			http_util.Error(w, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http_util.ReportError(w, "Error reading birthday file", err)
+			return
+		}
+		for _, resource := range resources {
+			responses = append(
+				responses,
+				newResponse(
+					report.PropFind,
+					h.href(resource),
+					h.resourceProps(resource, withData)))
+		}
+	} else {
+		for _, href := range report.Hrefs {
+			path := href
+			if u, err := url.Parse(href); err == nil {
+				path = u.Path
+			}
+			resource, ok := h.find(collection, path)
+			if !ok {
+				responses = append(responses, &Response{Href: href, Missing: true})
+				continue
+			}
+			responses = append(
+				responses,
+				newResponse(
+					report.PropFind, href, h.resourceProps(resource, withData)))
+		}
+	}
+	WriteMultistatus(w, responses)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	collection, err := h.Open()
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	resource, ok := h.find(collection, r.URL.Path)
+	if !ok {
+		http_util.Error(w, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", h.ContentType)
+	w.Header().Set("ETag", resource.ETag())
+	w.Write([]byte(resource.Data))
+}
+
+func (h *Handler) href(resource *Resource) string {
+	return h.CollectionPath() + url.PathEscape(resource.Name)
+}
+
+// find returns the resource of collection at path.
+func (h *Handler) find(collection Collection, path string) (*Resource, bool) {
+	name, ok := strings.CutPrefix(path, h.CollectionPath())
+	if !ok || name == "" {
+		return nil, false
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return collection.Find(name)
+}
+
+func (h *Handler) resourceProps(
+	resource *Resource, withData bool) []Property {
+	result := []Property{
+		Prop(NSDAV, "resourcetype", ""),
+		Prop(NSDAV, "getetag", Escape(resource.ETag())),
+		Prop(NSDAV, "getcontenttype", h.ContentType),
+	}
+	if withData {
+		result = append(
+			result, Prop(h.Data.Space, h.Data.Local, Escape(resource.Data)))
+	}
+	return result
+}
+
+func newResponse(
+	propFind *PropFind, href string, props []Property) *Response {
+	found, notFound := propFind.Select(props)
+	return &Response{Href: href, Props: found, NotFound: notFound}
+}
@@ -0,0 +1,214 @@
+package edit
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kXsrfAction = "/person/edit"
+	kXsrfExpire = 15 * time.Minute
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Birthdays</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  form {
+    font-size: 30px;
+  }
+  input {
+    font-size: 30px;
+  }
+  .error {
+    color: red;
+  }
+  </style>
+</head>
+<body>
+  {{if .Id}}
+    <h1>Edit person</h1>
+  {{else}}
+    <h1>New person</h1>
+  {{end}}
+  {{with .Error}}
+    <p class="error">{{.}}</p>
+  {{end}}
+  <form method="post">
+    <input type="hidden" name="xsrf" value="{{.Xsrf}}">
+    <input type="hidden" name="id" value="{{.Id}}">
+    Name: <input type="text" name="name" value="{{.Get "name"}}"><br>
+    Birthday: <input type="text" name="birthday" value="{{.Get "birthday"}}">
+    (mm/dd/yyyy or mm/dd)<br>
+    <input type="submit" name="save" value="Save">
+    {{if .Id}}
+      <input type="submit" name="delete" value="Delete"
+          onclick="return confirm('Delete {{.Get "name"}}?')">
+    {{end}}
+    {{if .Id}}
+      <a href="{{.PersonLink}}">Cancel</a>
+    {{else}}
+      <a href="/search">Cancel</a>
+    {{end}}
+  </form>
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+// Handler serves /person/new and /person/edit. If the id parameter is
+// present, Handler edits or deletes the entry with that id; otherwise it
+// adds a new entry. On success, Handler redirects to /search. If the
+// change conflicts with the birthday file, Handler shows the form again
+// with a message.
+type Handler struct {
+	Store birthday.WritableStore
+	Xsrf  *common.Xsrf
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	id := r.Form.Get("id")
+	if r.Method != http.MethodPost {
+		h.doGet(w, id)
+		return
+	}
+	if !h.Xsrf.VerifyToken(r.Form.Get("xsrf"), kXsrfAction, h.Clock.Now()) {
+		h.showForm(w, r.Form, id, "Form expired. Please try again.")
+		return
+	}
+	if http_util.HasParam(r.Form, "delete") {
+		if id == "" {
+			http_util.Error(w, http.StatusBadRequest)
+			return
+		}
+		h.finish(w, r, id, "deleted", h.Store.Delete(id))
+		return
+	}
+	entry, errMessage := parseEntry(r.Form)
+	if errMessage != "" {
+		h.showForm(w, r.Form, id, errMessage)
+		return
+	}
+	if id == "" {
+		_, err := h.Store.Add(entry)
+		h.finish(w, r, id, "added", err)
+		return
+	}
+	h.finish(w, r, id, "updated", h.Store.Update(id, entry))
+}
+
+func (h *Handler) doGet(w http.ResponseWriter, id string) {
+	values := make(map[string][]string)
+	if id != "" {
+		entry, ok, err := common.FindEntry(h.Store, id)
+		if err != nil {
+			http_util.ReportError(w, "Error reading birthday file", err)
+			return
+		}
+		if !ok {
+			http_util.Error(w, http.StatusNotFound)
+			return
+		}
+		values["name"] = []string{entry.Name}
+		values["birthday"] = []string{birthday.ToString(entry.Birthday)}
+	}
+	h.showForm(w, values, id, "")
+}
+
+func (h *Handler) showForm(
+	w http.ResponseWriter,
+	values map[string][]string,
+	id string,
+	errMessage string) {
+	http_util.WriteTemplate(w, kTemplate, &view{
+		Values: http_util.Values{Values: values},
+		Id:     id,
+		Error:  errMessage,
+		Xsrf: h.Xsrf.NewToken(
+			kXsrfAction, h.Clock.Now().Add(kXsrfExpire)),
+	})
+}
+
+// finish redirects to /search with status if err is nil. finish shows
+// the form again with a message if the user can fix err. Otherwise finish
+// redirects to /search with a status describing err.
+func (h *Handler) finish(
+	w http.ResponseWriter,
+	r *http.Request,
+	id string,
+	status string,
+	err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, birthday.ErrDuplicateEntry):
+		h.showForm(
+			w,
+			r.Form,
+			id,
+			"Someone with this name and birthday already exists.")
+		return
+	case errors.Is(err, birthday.ErrConcurrentEdit):
+		h.showForm(
+			w,
+			r.Form,
+			id,
+			"The birthday file changed while you were editing. "+
+				"Please check your changes and try again.")
+		return
+	case errors.Is(err, birthday.ErrNoSuchId):
+		status = "gone"
+	default:
+		log.Printf("Error writing birthday file: %v", err)
+		status = "failed"
+	}
+	http_util.Redirect(
+		w, r, http_util.NewUrl("/search", "status", status).String())
+}
+
+func parseEntry(values map[string][]string) (birthday.Entry, string) {
+	v := http_util.Values{Values: values}
+	name := strings.TrimSpace(v.Get("name"))
+	if name == "" {
+		return birthday.Entry{}, "Name is required."
+	}
+	bday, err := birthday.Parse(v.Get("birthday"))
+	if err != nil {
+		return birthday.Entry{}, "Birthday must be a valid mm/dd/yyyy or mm/dd."
+	}
+	return birthday.Entry{Name: name, Birthday: bday}, ""
+}
+
+type view struct {
+	http_util.Values
+	Id    string
+	Error string
+	Xsrf  string
+}
+
+func (v *view) PersonLink() *url.URL {
+	return common.PersonLink(v.Id)
+}
+
+func init() {
+	kTemplate = common.NewTemplate("edit", kTemplateSpec)
+}
@@ -0,0 +1,248 @@
+// Package feed serves upcoming milestones as Atom and RSS feeds.
+package feed
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kFeedTitle = "Birthdays"
+
+	// The date in the tag URIs of feeds and items. Never change it or
+	// feed readers will show every milestone again.
+	kTagDate = "2024"
+)
+
+// Format is the format of a feed.
+type Format int
+
+const (
+	Atom Format = iota
+	RSS
+)
+
+// Handler serves a feed with one item per upcoming milestone. Handler
+// honors the same q, p, date and days parameters as /home. The date of
+// each item is the date of its milestone. The id of each item stays the
+// same as long as the milestone does so that feed readers show each
+// milestone exactly once.
+type Handler struct {
+	Store birthday.Store
+
+	// The default number of days the feed covers
+	DaysAhead int
+
+	// The maximum number of items in the feed
+	MaxRows int
+
+	DefaultPeriods []birthday.Period
+
+	// Used to make item ids globally unique
+	Domain string
+
+	// Optional. The scheme and host that links in the feed start with
+	// e.g "https://birthdays.example.com". Set it when the server runs
+	// behind a proxy. The default is the scheme and host of the request.
+	BaseURL string
+
+	// Optional. Rules for hiding milestones
+	Mute *mute.Rules
+
+	Format Format
+
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	today, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endDate := today.AddDate(
+		0, 0, common.ParseDays(r.Form.Get("days"), h.DaysAhead))
+	seq := h.Mute.Filter(common.Milestones(
+		entries,
+		common.ParsePeriods(r.Form.Get("p"), h.DefaultPeriods),
+		today,
+		endDate))
+	base := h.baseURL(r)
+	var items []*item
+	for m := range itertools.Take(h.MaxRows, seq) {
+		items = append(items, &item{
+			Id:          h.id(m),
+			Title:       ical.Summary(m),
+			Link:        base + common.PersonLink(m.EntryPtr.Id).String(),
+			Description: birthday.ToStringWithWeekDay(m.Date),
+			Date:        m.Date,
+		})
+	}
+	var contentType string
+	var doc interface{}
+	switch h.Format {
+	case RSS:
+		contentType = "application/rss+xml; charset=utf-8"
+		doc = newRSS(base, h.Clock.Now(), items)
+	default:
+		contentType = "application/atom+xml; charset=utf-8"
+		doc = newAtom(base, h.id(nil), r.URL.String(), h.Clock.Now(), items)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(xml.Header))
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	encoder.Encode(doc)
+}
+
+// id returns the tag URI of milestone or of the feed itself if
+// milestone is nil.
+func (h *Handler) id(milestone *birthday.Milestone) string {
+	result := "tag:" + h.Domain + "," + kTagDate + ":"
+	if milestone == nil {
+		return result + "feed"
+	}
+	return result + milestone.Key()
+}
+
+// baseURL returns BaseURL or, if BaseURL is empty, the scheme and host
+// of the server handling r.
+func (h *Handler) baseURL(r *http.Request) string {
+	if h.BaseURL != "" {
+		return strings.TrimSuffix(h.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// item is a format neutral feed item.
+type item struct {
+	Id          string
+	Title       string
+	Link        string
+	Description string
+	Date        time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Id      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	Id        string   `xml:"id"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary"`
+}
+
+func newAtom(
+	base, id, self string, now time.Time, items []*item) *atomFeed {
+	result := &atomFeed{
+		Title:   kFeedTitle,
+		Id:      id,
+		Updated: now.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Href: base + self},
+			{Rel: "alternate", Href: base + "/home"},
+		},
+		Author: atomAuthor{Name: kFeedTitle},
+	}
+	for _, i := range items {
+		date := i.Date.Format(time.RFC3339)
+		result.Entries = append(result.Entries, atomEntry{
+			Title:     i.Title,
+			Id:        i.Id,
+			Link:      atomLink{Rel: "alternate", Href: i.Link},
+			Published: date,
+			Updated:   date,
+			Summary:   i.Description,
+		})
+	}
+	return result
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	Guid        rssGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSS(base string, now time.Time, items []*item) *rssFeed {
+	result := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         kFeedTitle,
+			Link:          base + "/home",
+			Description:   "Upcoming birthdays and milestones",
+			LastBuildDate: now.Format(time.RFC1123Z),
+		},
+	}
+	for _, i := range items {
+		result.Channel.Items = append(result.Channel.Items, rssItem{
+			Title:       i.Title,
+			Link:        i.Link,
+			Description: i.Description,
+			Guid:        rssGuid{Value: i.Id},
+			PubDate:     i.Date.Format(time.RFC1123Z),
+		})
+	}
+	return result
+}
@@ -3,14 +3,17 @@ package home
 import (
 	"fmt"
 	"html/template"
-	"iter"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ack"
+	"github.com/keep94/birthday/cmd/remind/acknowledge"
 	"github.com/keep94/birthday/cmd/remind/common"
-	"github.com/keep94/consume2"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/mute"
 	"github.com/keep94/itertools"
 	"github.com/keep94/toolbox/date_util"
 	"github.com/keep94/toolbox/http_util"
@@ -21,6 +24,8 @@ var (
 <html>
 <head>
   <title>Birthdays</title>
+  <link rel="alternate" type="application/atom+xml" title="Birthdays" href="/feed.atom">
+  <link rel="alternate" type="application/rss+xml" title="Birthdays" href="/feed.rss">
   <style>
   h1 {
     font-size: 40px;
@@ -34,6 +39,19 @@ var (
   td.today {
     font-style: italic;
   }
+  form {
+    font-size: 20px;
+  }
+  .error {
+    color: red;
+    font-size: 30px;
+  }
+  input {
+    font-size: 20px;
+  }
+  form.ack {
+    margin: 0;
+  }
   </style>
 </head>
 <body>
@@ -42,22 +60,57 @@ var (
   {{else}}
       <h1>Birthdays</h1>
   {{end}}
+  <p><a href="/calendar">Calendar</a> <a href="/search">Search</a> <a href="/notifications">Notifications</a> <a href="/mute">Muted</a></p>
+  <form>
+    From: <input type="text" name="from" value="{{.From}}" size="10" placeholder="mm/dd or -3d">
+    To: <input type="text" name="to" value="{{.Get "to"}}" size="10" placeholder="mm/dd or +2w">
+    Types: <input type="text" name="p" value="{{.Get "p"}}" size="5" placeholder="ymwdh">
+    Name: <input type="text" name="q" value="{{.Get "q"}}" size="15">
+    {{if .AcksOn}}
+    <label><input type="checkbox" name="hide" value="done" {{if .HideDone}}checked{{end}}> Hide done</label>
+    {{end}}
+    <input type="submit" value="Show">
+  </form>
+  {{with .Error}}
+    <p class="error">{{.}}</p>
+  {{end}}
   <table border=1>
     <tr>
       <th>Date</th>
       <th>Name</th>
       <th>Age</th>
+      <th>When</th>
+      {{if .AcksOn}}<th>Done</th>{{end}}
     </tr>
     {{with $top := .}}
     {{range .Milestones}}
     <tr>
       <td {{if $top.Today .}}class="today"{{end}}>{{$top.DateStr .}}</td>
-      <td {{if $top.Today .}}class="today"{{end}}>{{.EntryPtr.Name}}</td>
+      <td {{if $top.Today .}}class="today"{{end}}><a href="{{$top.PersonLink .}}">{{.EntryPtr.Name}}</a></td>
       <td {{if $top.Today .}}class="today"{{end}}>{{.AgeString}}</td>
+      <td {{if $top.Today .}}class="today"{{end}}>{{$top.When .}}</td>
+      {{if $top.AcksOn}}
+      <td {{if $top.Today .}}class="today"{{end}}>
+        <form method="post" action="/ack" class="ack">
+          <input type="hidden" name="key" value="{{.Key}}">
+          <input type="hidden" name="xsrf" value="{{$top.Xsrf}}">
+          <input type="hidden" name="return" value="{{$top.Return}}">
+          {{with $top.Ack .}}
+            &#10003; {{.Note}}
+            <input type="submit" name="undo" value="Undo">
+          {{else}}
+            <input type="text" name="note" size="12" placeholder="note">
+            <input type="submit" value="Done">
+          {{end}}
+        </form>
+      </td>
+      {{end}}
     </tr>
     {{end}}
     {{end}}
   </table>
+  {{if .PrevLink}}<a href="{{.PrevLink}}">&lt; Previous</a>{{end}}
+  {{if .NextLink}}<a href="{{.NextLink}}">Next &gt;</a>{{end}}
 </body>
 </html>`
 )
@@ -66,59 +119,195 @@ var (
 	kTemplate *template.Template
 )
 
+// Handler serves /home. It shows at most DefaultRows milestones per
+// page. The rows parameter changes the number of milestones per page up
+// to MaxRows. If Acks is set, each row has a button to mark the
+// milestone done, and hide=done hides the milestones marked done.
 type Handler struct {
 	Store          birthday.Store
 	DaysAhead      int
+	DefaultRows    int
 	MaxRows        int
 	BuildId        string
 	DefaultPeriods []birthday.Period
-	Clock          date_util.Clock
+
+	// Optional. Acknowledged milestones.
+	Acks *ack.Store
+
+	// Optional. Rules for hiding milestones
+	Mute *mute.Rules
+
+	// For the forms that mark milestones done
+	Xsrf *common.Xsrf
+
+	Clock date_util.Clock
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
-	var entries []*birthday.Entry
-	err := h.Store.Read(
-		consume2.Filter(
-			consume2.AppendPtrsTo(&entries),
-			birthday.Query(r.Form.Get("q"))))
+	w.Header().Add("Vary", "Accept")
+	format, err := common.NegotiateFormat(r)
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	after, err := common.ParseCursorParam(r.Form.Get("cursor"))
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	before, err := common.ParseCursorParam(r.Form.Get("before"))
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
 	if err != nil {
 		fmt.Fprintln(w, err)
 		return
 	}
-	daysAhead := h.parseDays(r.Form.Get("days"))
-	today := common.ParseDate(h.Clock, r.Form.Get("date"))
-	endDate := today.AddDate(0, 0, daysAhead)
-	seq := birthday.RemindPtrs(
+	fromStr := r.Form.Get("from")
+	if fromStr == "" {
+		fromStr = r.Form.Get("date")
+	}
+	today, endDate, err := dateparse.ParseRange(
+		h.Clock,
+		fromStr,
+		r.Form.Get("to"),
+		common.ParseDays(r.Form.Get("days"), h.DaysAhead))
+	if err != nil {
+		if format != common.HTML {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		http_util.WriteTemplate(w, kTemplate, &view{
+			Values:  http_util.Values{Values: r.Form},
+			From:    fromStr,
+			Error:   err.Error(),
+			BuildId: h.BuildId,
+		})
+		return
+	}
+	start := today
+	if after != nil {
+		start = after.Date
+	}
+	seq := h.Mute.Filter(common.Milestones(
 		entries,
 		common.ParsePeriods(r.Form.Get("p"), h.DefaultPeriods),
-		today)
-	seq = itertools.TakeWhile(
-		func(m *birthday.Milestone) bool { return m.Date.Before(endDate) },
-		seq)
-	seq = itertools.Take(h.MaxRows, seq)
-	http_util.WriteTemplate(
-		w, kTemplate, &view{Milestones: seq, BuildId: h.BuildId, today: today})
+		start,
+		endDate))
+	hideDone := h.Acks != nil && r.Form.Get("hide") == "done"
+	if hideDone {
+		seq = itertools.Filter(
+			func(m *birthday.Milestone) bool {
+				_, done := h.Acks.Get(m.Key())
+				return !done
+			},
+			seq)
+	}
+	page := common.Paginate(
+		entries,
+		seq,
+		after,
+		before,
+		h.parseRows(r.Form.Get("rows")))
+	if common.WriteMilestones(w, format, page, today, endDate) {
+		return
+	}
+	v := &view{
+		Values:     http_util.Values{Values: r.Form},
+		From:       fromStr,
+		Milestones: page.Milestones,
+		BuildId:    h.BuildId,
+		HideDone:   hideDone,
+		Return:     r.URL.RequestURI(),
+		today:      today,
+		now:        birthday.Today(h.Clock),
+		acks:       h.Acks,
+	}
+	if h.Acks != nil {
+		v.Xsrf = h.Xsrf.NewToken(
+			acknowledge.XsrfAction,
+			h.Clock.Now().Add(acknowledge.XsrfExpire))
+	}
+	if page.Prev != nil {
+		v.PrevLink = pageLink(r.URL, "before", page.Prev)
+	}
+	if page.Next != nil {
+		v.NextLink = pageLink(r.URL, "cursor", page.Next)
+	}
+	http_util.WriteTemplate(w, kTemplate, v)
 }
 
-func (h *Handler) parseDays(daysStr string) int {
-	result, err := strconv.Atoi(daysStr)
-	if err != nil {
-		return h.DaysAhead
+func (h *Handler) parseRows(rowsStr string) int {
+	result, err := strconv.Atoi(rowsStr)
+	if err != nil || result < 1 {
+		return h.DefaultRows
 	}
-	return result
+	return min(result, h.MaxRows)
+}
+
+// pageLink returns u with its paging parameters replaced by a name
+// parameter for cursor.
+func pageLink(u *url.URL, name string, cursor *common.Cursor) *url.URL {
+	result := *u
+	values := result.Query()
+	values.Del("cursor")
+	values.Del("before")
+	values.Set(name, cursor.String())
+	result.RawQuery = values.Encode()
+	return &result
 }
 
 type view struct {
-	Milestones iter.Seq[*birthday.Milestone]
+	http_util.Values
+	From       string
+	Error      string
+	Milestones []*birthday.Milestone
 	BuildId    string
+	PrevLink   *url.URL
+	NextLink   *url.URL
+	HideDone   bool
+	Xsrf       string
+	Return     string
 	today      time.Time
+	now        time.Time
+	acks       *ack.Store
+}
+
+// AcksOn returns true if milestones can be marked done.
+func (v *view) AcksOn() bool {
+	return v.acks != nil
+}
+
+// Ack returns the acknowledgement of milestone or nil if it is not done.
+func (v *view) Ack(milestone *birthday.Milestone) *ack.Ack {
+	if v.acks == nil {
+		return nil
+	}
+	result, ok := v.acks.Get(milestone.Key())
+	if !ok {
+		return nil
+	}
+	return &result
 }
 
 func (b *view) DateStr(milestone *birthday.Milestone) string {
 	return birthday.ToStringWithWeekDay(milestone.Date)
 }
 
+func (v *view) PersonLink(milestone *birthday.Milestone) *url.URL {
+	return common.PersonLink(milestone.EntryPtr.Id)
+}
+
+// When returns when milestone happens relative to the current date e.g
+// "tomorrow" or "in 7 days".
+func (v *view) When(milestone *birthday.Milestone) string {
+	return birthday.When(birthday.DaysUntil(v.now, milestone.Date))
+}
+
 func (v *view) Today(milestone *birthday.Milestone) bool {
 	return milestone.Date.Equal(v.today)
 }
@@ -0,0 +1,64 @@
+package ics
+
+import (
+	"net/http"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+// Handler serves /calendar.ics, an iCalendar feed of upcoming milestones.
+// Handler honors the same q, p, date and days parameters as /home.
+type Handler struct {
+	Store birthday.Store
+
+	// The default number of days the feed covers
+	DaysAhead int
+
+	// The maximum number of events in the feed
+	MaxRows int
+
+	DefaultPeriods []birthday.Period
+
+	// Used to make event UIDs globally unique
+	Domain string
+
+	// Optional. Rules for hiding milestones
+	Mute *mute.Rules
+
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	today, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	endDate := today.AddDate(
+		0, 0, common.ParseDays(r.Form.Get("days"), h.DaysAhead))
+	seq := h.Mute.Filter(common.Milestones(
+		entries,
+		common.ParsePeriods(r.Form.Get("p"), h.DefaultPeriods),
+		today,
+		endDate))
+	calendar := &ical.Calendar{
+		Name:   "Birthdays",
+		Domain: h.Domain,
+		Stamp:  h.Clock.Now(),
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	calendar.Write(w, itertools.Take(h.MaxRows, seq))
+}
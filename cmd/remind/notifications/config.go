@@ -0,0 +1,368 @@
+// Package notifications configures and reports on the notifications
+// the remind server sends.
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/notify"
+	"github.com/keep94/birthday/schedule"
+	"github.com/keep94/toolbox/date_util"
+)
+
+const (
+	kDefaultSchedule       = "0 8 * * *"
+	kDefaultWebhookRetries = 2
+)
+
+// Config is the JSON notification config file.
+type Config struct {
+
+	// The lead time. Each digest covers today through this many days
+	// from today for the milestones no policy covers.
+	DaysAhead int `json:"daysAhead"`
+
+	// Reminder policies. Each milestone uses the first policy that
+	// applies to it.
+	Policies []*PolicyConfig `json:"policies"`
+
+	// Only people matching query get notifications. Empty means everyone.
+	Query string `json:"query"`
+
+	// Period letters as in the p parameter of /home. Empty means the
+	// default periods.
+	Periods string `json:"periods"`
+
+	// When to send notifications as a cron expression. The default is
+	// 8:00 every day.
+	Schedule string `json:"schedule"`
+
+	// The time zone of Schedule e.g "America/New_York". The default is
+	// the local time zone.
+	Timezone string `json:"timezone"`
+
+	// Where to record when notifications last went out. The default is
+	// the config file path with ".state" appended.
+	StateFile string `json:"stateFile"`
+
+	// Where to log what went out when. The default is the config file
+	// path with ".log" appended.
+	SentLog string `json:"sentLog"`
+
+	location *time.Location
+
+	Email *EmailConfig `json:"email"`
+
+	Webhooks []*WebhookConfig `json:"webhooks"`
+
+	Commands []*CommandConfig `json:"commands"`
+}
+
+// EmailConfig configures email digests.
+type EmailConfig struct {
+
+	// host:port of the SMTP server
+	Addr string `json:"addr"`
+
+	// Username and Password are optional. If given, they are used for
+	// PLAIN authentication which net/smtp only allows over TLS or to
+	// localhost.
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+
+	Templates *TemplatesConfig `json:"templates"`
+
+	// Optional name for the delivery log and previews. Sinks must have
+	// different names.
+	Name string `json:"name"`
+}
+
+// TemplatesConfig gives the paths of template files. Empty paths mean
+// the built-in templates.
+type TemplatesConfig struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// templates parses the template files. templates returns nil if t is
+// nil.
+func (t *TemplatesConfig) templates() (*notify.Templates, error) {
+	if t == nil {
+		return nil, nil
+	}
+	return notify.ParseTemplateFiles(t.Subject, t.Text, t.HTML)
+}
+
+// PolicyConfig configures a reminder policy.
+type PolicyConfig struct {
+
+	// Period letters as in the p parameter of /home. Empty means the
+	// policy applies to all periods.
+	Periods string `json:"periods"`
+
+	// If non-zero, the policy applies only when the number of periods is
+	// a multiple of this, e.g 10 for birthdays ending in 0.
+	Every int `json:"every"`
+
+	// How many days ahead to send reminders. 0 means the day of.
+	LeadDays []int `json:"leadDays"`
+}
+
+func (p *PolicyConfig) check() error {
+	if err := common.CheckPeriods(p.Periods); err != nil {
+		return err
+	}
+	if p.Every < 0 {
+		return errors.New("every must be non-negative")
+	}
+	if len(p.LeadDays) == 0 {
+		return errors.New("policy needs leadDays")
+	}
+	for _, lead := range p.LeadDays {
+		if lead < 0 {
+			return errors.New("leadDays must be non-negative")
+		}
+	}
+	return nil
+}
+
+func (p *PolicyConfig) policy() notify.Policy {
+	return notify.Policy{
+		Periods:  common.ParsePeriods(p.Periods, nil),
+		Every:    p.Every,
+		LeadDays: p.LeadDays,
+	}
+}
+
+// WebhookConfig configures a webhook that gets digests.
+type WebhookConfig struct {
+	URL string `json:"url"`
+
+	// "generic" (the default), "slack", or "mattermost"
+	Format string `json:"format"`
+
+	// Optional secret for signing requests
+	Secret string `json:"secret"`
+
+	// The default is 10 seconds.
+	TimeoutSeconds int `json:"timeoutSeconds"`
+
+	// The default is 2.
+	Retries *int `json:"retries"`
+
+	// Only the text template is used, and only for Slack payloads.
+	Templates *TemplatesConfig `json:"templates"`
+
+	// Optional name for the delivery log and previews. Sinks must have
+	// different names.
+	Name string `json:"name"`
+}
+
+// CommandConfig configures a command to run for each digest or each
+// milestone.
+type CommandConfig struct {
+
+	// The command and its arguments
+	Command []string `json:"command"`
+
+	// If true, run once per milestone instead of once per digest.
+	PerMilestone bool `json:"perMilestone"`
+
+	// The default is 30 seconds.
+	TimeoutSeconds int `json:"timeoutSeconds"`
+
+	// Optional name for the delivery log and previews. Sinks must have
+	// different names.
+	Name string `json:"name"`
+}
+
+// Load reads the config file at path.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result Config
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	if err := common.CheckPeriods(result.Periods); err != nil {
+		return nil, err
+	}
+	if result.Schedule == "" {
+		result.Schedule = kDefaultSchedule
+	}
+	if result.StateFile == "" {
+		result.StateFile = path + ".state"
+	}
+	if result.SentLog == "" {
+		result.SentLog = path + ".log"
+	}
+	result.location = time.Local
+	if result.Timezone != "" {
+		result.location, err = time.LoadLocation(result.Timezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, policy := range result.Policies {
+		if err := policy.check(); err != nil {
+			return nil, err
+		}
+	}
+	if result.DaysAhead < 0 {
+		return nil, errors.New("daysAhead must be non-negative")
+	}
+	return &result, nil
+}
+
+// Notifier returns the notifier this config describes.
+func (c *Config) Notifier(
+	store birthday.Store, clock date_util.Clock) (*notify.Notifier, error) {
+	sentLog, err := notify.OpenSentLog(c.SentLog)
+	if err != nil {
+		return nil, err
+	}
+	result := &notify.Notifier{
+		Store:     store,
+		Query:     c.Query,
+		Periods:   common.ParsePeriods(c.Periods, birthday.DefaultPeriods),
+		DaysAhead: c.DaysAhead,
+		Log:       sentLog,
+		Clock:     zoneClock{Clock: clock, Location: c.location},
+	}
+	for _, policy := range c.Policies {
+		result.Policies = append(result.Policies, policy.policy())
+	}
+	if c.Email != nil {
+		email, err := c.Email.sink()
+		if err != nil {
+			return nil, err
+		}
+		email.Clock = result.Clock
+		result.Sinks = append(result.Sinks, email)
+	}
+	for _, webhook := range c.Webhooks {
+		sink, err := webhook.sink()
+		if err != nil {
+			return nil, err
+		}
+		result.Sinks = append(result.Sinks, sink)
+	}
+	for _, command := range c.Commands {
+		if len(command.Command) == 0 {
+			return nil, errors.New("command can't be empty")
+		}
+		result.Sinks = append(result.Sinks, &notify.Exec{
+			Command:      command.Command,
+			PerMilestone: command.PerMilestone,
+			Timeout:      time.Duration(command.TimeoutSeconds) * time.Second,
+			Channel:      command.Name,
+		})
+	}
+	names := make(map[string]bool)
+	for _, sink := range result.Sinks {
+		if names[sink.Name()] {
+			return nil, fmt.Errorf(
+				"more than one %q: give each a different name", sink.Name())
+		}
+		names[sink.Name()] = true
+	}
+	return result, nil
+}
+
+// Scheduler returns a scheduler that runs notifier as this config
+// describes.
+func (c *Config) Scheduler(
+	notifier *notify.Notifier, clock date_util.Clock) (
+	*schedule.Scheduler, error) {
+	sched, err := schedule.Parse(c.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &schedule.Scheduler{
+		Jobs: []*schedule.Job{
+			{Name: "notify", Schedule: sched, Run: notifier.Notify},
+		},
+		Location:  c.location,
+		StateFile: c.StateFile,
+		Clock:     clock,
+	}, nil
+}
+
+// zoneClock reports the time in the notification time zone so that
+// today's digest is for the date in that time zone.
+type zoneClock struct {
+	date_util.Clock
+	Location *time.Location
+}
+
+func (z zoneClock) Now() time.Time {
+	return z.Clock.Now().In(z.Location)
+}
+
+func (e *EmailConfig) sink() (*notify.Email, error) {
+	if e.Addr == "" || e.From == "" || len(e.To) == 0 {
+		return nil, errors.New("email needs addr, from and to")
+	}
+	templates, err := e.Templates.templates()
+	if err != nil {
+		return nil, err
+	}
+	result := &notify.Email{
+		Addr:      e.Addr,
+		From:      e.From,
+		To:        e.To,
+		Subject:   e.Subject,
+		Templates: templates,
+		Channel:   e.Name,
+	}
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return nil, err
+		}
+		result.Auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	return result, nil
+}
+
+func (w *WebhookConfig) sink() (*notify.Webhook, error) {
+	if w.URL == "" {
+		return nil, errors.New("webhook needs url")
+	}
+	format, err := notify.ParsePayloadFormat(w.Format)
+	if err != nil {
+		return nil, err
+	}
+	retries := kDefaultWebhookRetries
+	if w.Retries != nil {
+		retries = *w.Retries
+	}
+	templates, err := w.Templates.templates()
+	if err != nil {
+		return nil, err
+	}
+	return &notify.Webhook{
+		URL:       w.URL,
+		Format:    format,
+		Secret:    w.Secret,
+		Timeout:   time.Duration(w.TimeoutSeconds) * time.Second,
+		Retries:   retries,
+		Templates: templates,
+		Channel:   w.Name,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package notifications
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/notify"
+	"github.com/keep94/toolbox/http_util"
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Notifications</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  th {
+    font-size: 30px;
+  }
+  td {
+    font-size: 30px;
+  }
+  p {
+    font-size: 30px;
+  }
+  .error {
+    color: red;
+  }
+  </style>
+</head>
+<body>
+  <h1>Notifications</h1>
+  <p>
+    <a href="/home">Home</a>
+    {{if .FailedOnly}}
+      <a href="/notifications">Show all</a>
+    {{else}}
+      <a href="/notifications?failed=1">Show failures only</a>
+    {{end}}
+  </p>
+  {{range .Channels}}
+    <p>
+      Preview {{.}}:
+      <a href="/notifications/preview?channel={{.}}&part=subject">subject</a>
+      <a href="/notifications/preview?channel={{.}}&part=text">text</a>
+      <a href="/notifications/preview?channel={{.}}&part=html">HTML</a>
+    </p>
+  {{end}}
+  {{if not .Configured}}
+    <p>Notifications are not set up. Start the server with -notify_config.</p>
+  {{else if not .Entries}}
+    <p>Nothing sent yet.</p>
+  {{else}}
+  <table border=1>
+    <tr>
+      <th>Sent</th>
+      <th>Channel</th>
+      <th>Name</th>
+      <th>Date</th>
+      <th>Age</th>
+      <th>Reminder</th>
+      <th>Result</th>
+    </tr>
+    {{with $top := .}}
+    {{range .Entries}}
+    <tr>
+      <td>{{.Time.Format "2006-01-02 15:04"}}</td>
+      <td>{{.Channel}}</td>
+      <td><a href="{{$top.PersonLink .}}">{{.Name}}</a></td>
+      <td>{{.Date}}</td>
+      <td>{{.Age}}</td>
+      <td>{{$top.Reminder .}}</td>
+      {{if .Succeeded}}
+        <td>OK</td>
+      {{else}}
+        <td class="error">{{.Error}}</td>
+      {{end}}
+    </tr>
+    {{end}}
+    {{end}}
+  </table>
+  {{end}}
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+// Handler serves /notifications, the most recent delivery attempts,
+// newest first. With failed=1, Handler shows only failed attempts.
+// Handler also links to previews of the messages of each channel.
+type Handler struct {
+
+	// nil means notifications are not set up.
+	Notifier *notify.Notifier
+
+	// The maximum number of attempts to show
+	MaxRows int
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	failedOnly := r.Form.Get("failed") != ""
+	var entries []*notify.LogEntry
+	var channels []string
+	if h.Notifier != nil {
+		for _, sink := range h.Notifier.Sinks {
+			if _, ok := sink.(notify.Renderer); ok {
+				channels = append(channels, sink.Name())
+			}
+		}
+	}
+	if h.Notifier != nil && h.Notifier.Log != nil {
+		for _, entry := range h.Notifier.Log.Entries() {
+			if len(entries) == h.MaxRows {
+				break
+			}
+			if !failedOnly || !entry.Succeeded() {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	http_util.WriteTemplate(w, kTemplate, &view{
+		Configured: h.Notifier != nil,
+		FailedOnly: failedOnly,
+		Channels:   channels,
+		Entries:    entries,
+	})
+}
+
+type view struct {
+	Configured bool
+	FailedOnly bool
+	Channels   []string
+	Entries    []*notify.LogEntry
+}
+
+func (v *view) PersonLink(entry *notify.LogEntry) *url.URL {
+	entryId, _, _, err := birthday.ParseMilestoneKey(entry.Key)
+	if err != nil {
+		return &url.URL{}
+	}
+	return common.PersonLink(entryId)
+}
+
+// Reminder returns which reminder entry was e.g "in 7 days" or "today".
+// Reminder returns "" if no policy covered the milestone.
+func (v *view) Reminder(entry *notify.LogEntry) string {
+	lead, err := strconv.Atoi(entry.Lead)
+	if err != nil {
+		return ""
+	}
+	return birthday.When(lead)
+}
+
+func init() {
+	kTemplate = common.NewTemplate("notifications", kTemplateSpec)
+}
@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/notify"
+	"github.com/keep94/toolbox/http_util"
+)
+
+// PreviewHandler serves /notifications/preview which renders a
+// notification template against the milestones of a day without sending
+// anything. The channel parameter picks the sink by name and defaults to
+// the first sink that renders messages. The part parameter is html,
+// text or subject and defaults to html if the channel has an HTML body
+// and text otherwise. The date parameter picks the day and defaults to
+// today. The preview ignores what has already been sent.
+type PreviewHandler struct {
+
+	// nil means notifications are not set up.
+	Notifier *notify.Notifier
+}
+
+func (h *PreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if h.Notifier == nil {
+		http_util.Error(w, http.StatusNotFound)
+		return
+	}
+	renderer := findRenderer(h.Notifier.Sinks, r.Form.Get("channel"))
+	if renderer == nil {
+		http_util.Error(w, http.StatusNotFound)
+		return
+	}
+	date, err := dateparse.ParseDate(h.Notifier.Clock, r.Form.Get("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	digest, err := h.Notifier.DigestOn(date)
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	message, err := renderer.Render(digest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	part := r.Form.Get("part")
+	if part == "" {
+		part = "text"
+		if message.HTML != "" {
+			part = "html"
+		}
+	}
+	switch part {
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(message.HTML))
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(message.Text))
+	case "subject":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(message.Subject))
+	default:
+		http_util.Error(w, http.StatusBadRequest)
+	}
+}
+
+// findRenderer returns the sink named channel if it renders messages.
+// If channel is empty, findRenderer returns the first sink that renders
+// messages. findRenderer returns nil if there is no such sink.
+func findRenderer(sinks []notify.Sink, channel string) notify.Renderer {
+	for _, sink := range sinks {
+		renderer, ok := sink.(notify.Renderer)
+		if ok && (channel == "" || sink.Name() == channel) {
+			return renderer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package person
+
+import (
+	"fmt"
+	"html/template"
+	"iter"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ack"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/cmd/remind/snooze"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	kTimelineWidth = 1000.0
+	kTimelineYears = 100
+)
+
+var (
+	kYears  = birthday.Period{Years: 1}
+	kMonths = birthday.Period{Months: 1}
+	kWeeks  = birthday.Period{Weeks: 1}
+	kDays   = birthday.Period{Days: 1}
+)
+
+var (
+	// The major milestones shown on the lifetime timeline.
+	kMajorPeriods = []birthday.Period{
+		{Years: 10},
+		{Months: 500},
+		{Weeks: 1000},
+		{Days: 10000},
+	}
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Birthdays</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  h2 {
+    font-size: 35px;
+  }
+  p {
+    font-size: 30px;
+  }
+  th {
+    font-size: 30px;
+  }
+  td {
+    font-size: 30px;
+  }
+  </style>
+</head>
+<body>
+  <h1>{{.Entry.Name}}</h1>
+  <p>
+    Born: {{.BirthdayStr}}
+    {{if .Writable}}<a href="{{.EditLink}}">Edit</a>{{end}}
+    <a href="/search">Back</a>
+  </p>
+  {{if .MuteOn}}
+  <form method="post" action="/mute">
+    <input type="hidden" name="xsrf" value="{{.Xsrf}}">
+    <input type="hidden" name="return" value="{{.Return}}">
+    <input type="hidden" name="id" value="{{.Entry.Id}}">
+    {{with .Muted}}
+    <p>
+      Muted {{if .Forever}}for good{{else}}until {{$.UntilStr .}}{{end}}
+      <input type="hidden" name="remove" value="1">
+      <input type="submit" value="Unmute">
+    </p>
+    {{else}}
+    <p>
+      <input type="text" name="until" placeholder="Until (blank for good)">
+      <input type="submit" value="Mute">
+    </p>
+    {{end}}
+  </form>
+  {{end}}
+  {{if .HasYear}}
+  <table border=1>
+    <tr>
+      <th>Years</th>
+      <th>Months</th>
+      <th>Weeks</th>
+      <th>Days</th>
+    </tr>
+    <tr>
+      <td>{{.InUnit .Years}}</td>
+      <td>{{.InUnit .Months}}</td>
+      <td>{{.InUnit .Weeks}}</td>
+      <td>{{.InUnit .Days}}</td>
+    </tr>
+  </table>
+  <h2>Timeline</h2>
+  {{with .Timeline}}
+  <svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="120"
+      viewBox="0 0 {{.Width}} 120">
+    <line x1="0" y1="60" x2="{{.Width}}" y2="60" stroke="black"
+        stroke-width="2"/>
+    {{range .Marks}}
+    <line x1="{{.X}}" y1="50" x2="{{.X}}" y2="70" stroke="gray"/>
+    <text x="{{.X}}" y="{{.LabelY}}" font-size="10"
+        text-anchor="middle"><title>{{.Date}}</title>{{.Label}}</text>
+    {{end}}
+    <line x1="{{.TodayX}}" y1="30" x2="{{.TodayX}}" y2="90" stroke="red"
+        stroke-width="3"/>
+    <text x="{{.TodayX}}" y="100" font-size="10" fill="red"
+        text-anchor="middle">today</text>
+  </svg>
+  {{end}}
+  {{end}}
+  <h2>Upcoming</h2>
+  <table border=1>
+    <tr>
+      <th>Date</th>
+      <th>Age</th>
+      {{if .MuteOn}}<th>Hidden</th>{{end}}
+    </tr>
+    {{with $top := .}}
+    {{range .Upcoming}}
+    <tr>
+      <td>{{$top.DateStr .}}</td>
+      <td>{{.AgeString}}</td>
+      {{if $top.MuteOn}}
+      <td>
+        <form method="post" action="/mute">
+          <input type="hidden" name="xsrf" value="{{$top.Xsrf}}">
+          <input type="hidden" name="return" value="{{$top.Return}}">
+          <input type="hidden" name="key" value="{{.Key}}">
+          {{if $top.Hidden .}}
+          <input type="hidden" name="remove" value="1">
+          <input type="submit" value="Unhide">
+          {{else}}
+          <input type="submit" value="Hide">
+          {{end}}
+        </form>
+      </td>
+      {{end}}
+    </tr>
+    {{end}}
+    {{end}}
+  </table>
+  <h2>Past</h2>
+  <table border=1>
+    <tr>
+      <th>Date</th>
+      <th>Age</th>
+    </tr>
+    {{with $top := .}}
+    {{range .Past}}
+    <tr>
+      <td>{{$top.DateStr .}}</td>
+      <td>{{.AgeString}}</td>
+    </tr>
+    {{end}}
+    {{end}}
+  </table>
+  {{if .AcksOn}}
+  <h2>Done</h2>
+  {{with .Done}}
+  <table border=1>
+    <tr>
+      <th>Date</th>
+      <th>Age</th>
+      <th>Marked done</th>
+      <th>Note</th>
+    </tr>
+    {{range .}}
+    <tr>
+      <td>{{.DateStr}}</td>
+      <td>{{.Milestone.AgeString}}</td>
+      <td>{{.Ack.Time.Format "01/02/2006 15:04"}}</td>
+      <td>{{.Ack.Note}}</td>
+    </tr>
+    {{end}}
+  </table>
+  {{else}}
+  <p>Nothing marked done yet.</p>
+  {{end}}
+  {{end}}
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+// Handler serves the page for the person given by the id parameter.
+// The page shows the person's current age, upcoming and past milestones,
+// and a lifetime timeline.
+type Handler struct {
+	Store birthday.Store
+
+	// The periods for upcoming and past milestones
+	Periods []birthday.Period
+
+	// How many upcoming milestones to show for each period and how many
+	// past milestones to show.
+	Count int
+
+	// Optional. Acknowledged milestones.
+	Acks *ack.Store
+
+	// Optional. Rules for hiding milestones. Xsrf is required if Mute is
+	// set.
+	Mute *mute.Rules
+	Xsrf *common.Xsrf
+
+	// If true, the page links to the form for editing the person.
+	Writable bool
+
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	entry, ok, err := common.FindEntry(h.Store, r.Form.Get("id"))
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	if !ok {
+		http_util.Error(w, http.StatusNotFound)
+		return
+	}
+	today, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries := []*birthday.Entry{&entry}
+	v := &view{
+		Entry:    &entry,
+		Upcoming: h.upcoming(entries, today),
+		Past: itertools.Take(
+			h.Count, birthday.HistoryPtrs(entries, h.Periods, today)),
+		AcksOn:   h.Acks != nil,
+		Done:     h.done(&entry),
+		MuteOn:   h.Mute != nil,
+		Writable: h.Writable,
+		Return:   r.URL.RequestURI(),
+		rules:    h.Mute,
+		today:    today,
+	}
+	if h.Mute != nil {
+		if rule, ok := h.Mute.Person(entry.Id); ok {
+			v.Muted = &rule
+		}
+		v.Xsrf = h.Xsrf.NewToken(
+			snooze.XsrfAction, h.Clock.Now().Add(snooze.XsrfExpire))
+	}
+	http_util.WriteTemplate(w, kTemplate, v)
+}
+
+// done returns the milestones of entry marked done, most recently marked
+// first.
+func (h *Handler) done(entry *birthday.Entry) []*doneRow {
+	if h.Acks == nil {
+		return nil
+	}
+	var result []*doneRow
+	for _, a := range h.Acks.ForEntry(entry.Id) {
+		_, period, count, err := birthday.ParseMilestoneKey(a.Key)
+		if err != nil {
+			continue
+		}
+		milestone, ok := birthday.MilestoneAt(entry, period, count)
+		if !ok {
+			continue
+		}
+		result = append(result, &doneRow{Milestone: &milestone, Ack: a})
+	}
+	return result
+}
+
+// upcoming returns the next h.Count milestones for each period in
+// chronological order.
+func (h *Handler) upcoming(
+	entries []*birthday.Entry, today time.Time) []*birthday.Milestone {
+	var result []*birthday.Milestone
+	for _, period := range h.Periods {
+		result = slices.AppendSeq(
+			result,
+			itertools.Take(
+				h.Count,
+				birthday.RemindPtrs(
+					entries, []birthday.Period{period}, today)))
+	}
+	slices.SortStableFunc(
+		result,
+		func(m1, m2 *birthday.Milestone) int {
+			if m1.Less(m2) {
+				return -1
+			}
+			if m2.Less(m1) {
+				return 1
+			}
+			return 0
+		})
+	return result
+}
+
+type view struct {
+	Entry    *birthday.Entry
+	Upcoming []*birthday.Milestone
+	Past     iter.Seq[*birthday.Milestone]
+	AcksOn   bool
+	Done     []*doneRow
+	MuteOn   bool
+	Writable bool
+	Muted    *mute.Rule
+	Xsrf     string
+	Return   string
+	rules    *mute.Rules
+	today    time.Time
+}
+
+type doneRow struct {
+	Milestone *birthday.Milestone
+	Ack       ack.Ack
+}
+
+func (d *doneRow) DateStr() string {
+	return birthday.ToStringWithWeekDay(d.Milestone.Date)
+}
+
+// Hidden returns true if a rule hides milestone by itself.
+func (v *view) Hidden(milestone *birthday.Milestone) bool {
+	_, ok := v.rules.Milestone(milestone.Key())
+	return ok
+}
+
+func (v *view) UntilStr(rule *mute.Rule) string {
+	return birthday.ToString(rule.Until)
+}
+
+func (v *view) HasYear() bool {
+	return birthday.HasYear(v.Entry.Birthday)
+}
+
+func (v *view) BirthdayStr() string {
+	if v.HasYear() {
+		return birthday.ToStringWithWeekDay(v.Entry.Birthday)
+	}
+	return birthday.ToString(v.Entry.Birthday)
+}
+
+func (v *view) EditLink() *url.URL {
+	return common.EditLink(v.Entry.Id)
+}
+
+func (v *view) DateStr(milestone *birthday.Milestone) string {
+	return birthday.ToStringWithWeekDay(milestone.Date)
+}
+
+func (v *view) Years() birthday.Period  { return kYears }
+func (v *view) Months() birthday.Period { return kMonths }
+func (v *view) Weeks() birthday.Period  { return kWeeks }
+func (v *view) Days() birthday.Period   { return kDays }
+
+func (v *view) InUnit(period birthday.Period) string {
+	return strconv.Itoa(period.Diff(v.today, v.Entry.Birthday))
+}
+
+func (v *view) Timeline() *timeline {
+	return newTimeline(v.Entry, v.today)
+}
+
+// timeline is the lifetime timeline of a person drawn as SVG.
+type timeline struct {
+	Width  float64
+	Marks  []timelineMark
+	TodayX float64
+}
+
+type timelineMark struct {
+	X      float64
+	LabelY int
+	Label  string
+	Date   string
+}
+
+func newTimeline(entry *birthday.Entry, today time.Time) *timeline {
+	start := entry.Birthday
+	end := start.AddDate(kTimelineYears, 0, 0)
+	span := end.Sub(start).Hours()
+	xPos := func(t time.Time) float64 {
+		x := kTimelineWidth * t.Sub(start).Hours() / span
+		return math.Round(min(max(x, 0.0), kTimelineWidth)*10.0) / 10.0
+	}
+	result := &timeline{Width: kTimelineWidth, TodayX: xPos(today)}
+	milestones := itertools.TakeWhile(
+		func(m *birthday.Milestone) bool { return m.Date.Before(end) },
+		birthday.RemindPtrs(
+			[]*birthday.Entry{entry}, kMajorPeriods, start.AddDate(0, 0, 1)))
+	for m := range milestones {
+		labelY := 40
+		if len(result.Marks)%2 == 1 {
+			labelY = 85
+		}
+		result.Marks = append(result.Marks, timelineMark{
+			X:      xPos(m.Date),
+			LabelY: labelY,
+			Label:  shortAgeString(m.Age),
+			Date:   birthday.ToString(m.Date),
+		})
+	}
+	return result
+}
+
+func shortAgeString(age birthday.Period) string {
+	switch {
+	case age.Years != 0:
+		return fmt.Sprintf("%dy", age.Years)
+	case age.Months != 0:
+		return fmt.Sprintf("%dm", age.Months)
+	case age.Weeks != 0:
+		return fmt.Sprintf("%dw", age.Weeks)
+	default:
+		return fmt.Sprintf("%dd", age.Days)
+	}
+}
+
+func init() {
+	kTemplate = common.NewTemplate("person", kTemplateSpec)
+}
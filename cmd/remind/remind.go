@@ -3,12 +3,31 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 
 	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ack"
+	"github.com/keep94/birthday/cmd/remind/acknowledge"
+	"github.com/keep94/birthday/cmd/remind/api"
+	"github.com/keep94/birthday/cmd/remind/caldav"
+	"github.com/keep94/birthday/cmd/remind/calendar"
+	"github.com/keep94/birthday/cmd/remind/carddav"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/cmd/remind/edit"
+	"github.com/keep94/birthday/cmd/remind/feed"
 	"github.com/keep94/birthday/cmd/remind/home"
+	"github.com/keep94/birthday/cmd/remind/ics"
+	"github.com/keep94/birthday/cmd/remind/notifications"
+	"github.com/keep94/birthday/cmd/remind/person"
 	"github.com/keep94/birthday/cmd/remind/search"
+	"github.com/keep94/birthday/cmd/remind/snooze"
+	"github.com/keep94/birthday/cmd/remind/year"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/birthday/notify"
+	"github.com/keep94/birthday/schedule"
 	"github.com/keep94/context"
 	"github.com/keep94/toolbox/build"
 	"github.com/keep94/toolbox/date_util"
@@ -18,7 +37,10 @@ import (
 )
 
 const (
-	kMaxRows = 100
+	kMaxRows          = 100
+	kMaxApiRows       = 1000
+	kMaxIcsRows       = 5000
+	kPersonMilestones = 5
 )
 
 var (
@@ -26,9 +48,15 @@ var (
 )
 
 var (
-	fFile      string
-	fDaysAhead int
-	fPort      string
+	fFile         string
+	fDaysAhead    int
+	fIcsDaysAhead int
+	fPort         string
+	fNotifyConfig string
+	fNotifyNow    bool
+	fAckFile      string
+	fMuteFile     string
+	fBaseURL      string
 )
 
 func main() {
@@ -38,7 +66,46 @@ func main() {
 		flag.Usage()
 		os.Exit(1)
 	}
-	store := birthday.SystemStore(fFile)
+	store := ical.NewStore(fFile)
+	writableStore, writable := store.(birthday.WritableStore)
+	mutePath := fMuteFile
+	if mutePath == "" {
+		mutePath = fFile + ".mute"
+	}
+	rules, err := mute.Open(mutePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var notifier *notify.Notifier
+	if fNotifyConfig != "" {
+		var scheduler *schedule.Scheduler
+		notifier, scheduler, err = newNotifier(store, rules)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !fNotifyNow {
+			go scheduler.Run(nil)
+		}
+	}
+	if fNotifyNow {
+		if notifier == nil {
+			log.Fatal("-notify_now needs -notify_config")
+		}
+		if err := notifier.Notify(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	ackPath := fAckFile
+	if ackPath == "" {
+		ackPath = fFile + ".acks"
+	}
+	acks, err := ack.Open(ackPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ackXsrf := common.NewXsrf()
+	muteXsrf := common.NewXsrf()
 	http.HandleFunc("/", rootRedirect)
 	version, _ := build.MainVersion()
 	http.Handle(
@@ -46,11 +113,130 @@ func main() {
 		&home.Handler{
 			Store:          store,
 			DaysAhead:      fDaysAhead,
-			MaxRows:        kMaxRows,
+			DefaultRows:    kMaxRows,
+			MaxRows:        kMaxApiRows,
 			BuildId:        build.BuildId(version),
 			DefaultPeriods: birthday.DefaultPeriods,
+			Acks:           acks,
+			Xsrf:           ackXsrf,
+			Mute:           rules,
 			Clock:          kClock})
-	http.Handle("/search", &search.Handler{Store: store, Clock: kClock})
+	http.Handle(
+		"/ack",
+		&acknowledge.Handler{Acks: acks, Xsrf: ackXsrf, Clock: kClock})
+	http.Handle(
+		"/mute",
+		&snooze.Handler{
+			Store: store, Rules: rules, Xsrf: muteXsrf, Clock: kClock})
+	http.Handle(
+		"/calendar",
+		&calendar.Handler{
+			Store:          store,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/year",
+		&year.Handler{
+			Store:          store,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/search",
+		&search.Handler{Store: store, Writable: writable, Clock: kClock})
+	http.Handle(
+		"/notifications",
+		&notifications.Handler{Notifier: notifier, MaxRows: kMaxRows})
+	http.Handle(
+		"/notifications/preview",
+		&notifications.PreviewHandler{Notifier: notifier})
+	http.Handle(
+		"/person",
+		&person.Handler{
+			Store:    store,
+			Periods:  birthday.DefaultPeriods,
+			Count:    kPersonMilestones,
+			Acks:     acks,
+			Mute:     rules,
+			Xsrf:     muteXsrf,
+			Writable: writable,
+			Clock:    kClock})
+	http.Handle(
+		"/api/v1/milestones",
+		&api.MilestonesHandler{
+			Store:          store,
+			DaysAhead:      fDaysAhead,
+			DefaultLimit:   kMaxRows,
+			MaxLimit:       kMaxApiRows,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/api/v1/entries", &api.EntriesHandler{Store: store, Clock: kClock})
+	http.Handle(
+		"/calendar.ics",
+		&ics.Handler{
+			Store:          store,
+			DaysAhead:      fIcsDaysAhead,
+			MaxRows:        kMaxIcsRows,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Domain:         ical.DefaultDomain,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/feed.atom",
+		&feed.Handler{
+			Store:          store,
+			DaysAhead:      fDaysAhead,
+			MaxRows:        kMaxRows,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Domain:         ical.DefaultDomain,
+			BaseURL:        fBaseURL,
+			Format:         feed.Atom,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/feed.rss",
+		&feed.Handler{
+			Store:          store,
+			DaysAhead:      fDaysAhead,
+			MaxRows:        kMaxRows,
+			DefaultPeriods: birthday.DefaultPeriods,
+			Domain:         ical.DefaultDomain,
+			BaseURL:        fBaseURL,
+			Format:         feed.RSS,
+			Mute:           rules,
+			Clock:          kClock})
+	http.Handle(
+		"/caldav/",
+		&caldav.Handler{
+			Store:     store,
+			Prefix:    "/caldav/",
+			DaysAhead: fIcsDaysAhead,
+			MaxRows:   kMaxIcsRows,
+			Periods:   birthday.DefaultPeriods,
+			Domain:    ical.DefaultDomain,
+			Mute:      rules,
+			Clock:     kClock})
+	http.Handle(
+		"/.well-known/caldav",
+		http.RedirectHandler("/caldav/", http.StatusMovedPermanently))
+	http.Handle(
+		"/carddav/",
+		&carddav.Handler{
+			Store:  store,
+			Prefix: "/carddav/",
+			Domain: ical.DefaultDomain})
+	http.Handle(
+		"/.well-known/carddav",
+		http.RedirectHandler("/carddav/", http.StatusMovedPermanently))
+	if writable {
+		editHandler := &edit.Handler{
+			Store: writableStore, Xsrf: common.NewXsrf(), Clock: kClock}
+		http.Handle("/person/new", editHandler)
+		http.Handle("/person/edit", editHandler)
+	}
 	defaultHandler := context.ClearHandler(
 		weblogs.HandlerWithOptions(
 			http.DefaultServeMux,
@@ -61,6 +247,26 @@ func main() {
 	}
 }
 
+// newNotifier returns the notifier and the scheduler for it that the
+// notification config file describes.
+func newNotifier(store birthday.Store, rules *mute.Rules) (
+	*notify.Notifier, *schedule.Scheduler, error) {
+	config, err := notifications.Load(fNotifyConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifier, err := config.Notifier(store, kClock)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifier.Mute = rules
+	scheduler, err := config.Scheduler(notifier, kClock)
+	if err != nil {
+		return nil, nil, err
+	}
+	return notifier, scheduler, nil
+}
+
 func rootRedirect(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/" {
 		http_util.Redirect(w, r, "/home")
@@ -72,5 +278,29 @@ func rootRedirect(w http.ResponseWriter, r *http.Request) {
 func init() {
 	flag.StringVar(&fFile, "file", "", "Birthday file")
 	flag.IntVar(&fDaysAhead, "days_ahead", 21, "Days ahead")
+	flag.IntVar(
+		&fIcsDaysAhead, "ics_days_ahead", 365, "Days ahead in calendar feed")
 	flag.StringVar(&fPort, "http", ":8080", "Port to bind")
+	flag.StringVar(
+		&fNotifyConfig, "notify_config", "", "Notification config file")
+	flag.BoolVar(
+		&fNotifyNow,
+		"notify_now",
+		false,
+		"Send notifications once and exit instead of serving")
+	flag.StringVar(
+		&fAckFile,
+		"ack_file",
+		"",
+		"File of milestones marked done. Default is birthday file + .acks")
+	flag.StringVar(
+		&fMuteFile,
+		"mute_file",
+		"",
+		"File of muted people and milestones. Default is birthday file + .mute")
+	flag.StringVar(
+		&fBaseURL,
+		"base_url",
+		"",
+		"Scheme and host for links in feeds e.g https://example.com")
 }
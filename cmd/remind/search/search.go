@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/keep94/birthday"
 	"github.com/keep94/birthday/cmd/remind/common"
-	"github.com/keep94/consume2"
+	"github.com/keep94/birthday/dateparse"
 	"github.com/keep94/toolbox/date_util"
 	"github.com/keep94/toolbox/http_util"
 )
@@ -42,14 +43,29 @@ var (
   input {
     font-size: 30px;
   }
+  .error {
+    color: red;
+    font-size: 30px;
+  }
+  .status {
+    font-size: 30px;
+    font-style: italic;
+  }
   </style>
 </head>
 <body>
   <h1>Birthdays</h1>
+  {{with .StatusMessage}}
+    <p class="status">{{.}}</p>
+  {{end}}
   <form>
      Name: <input type="text" name="q" value="{{.Get "q"}}">
     <input type="submit" value="Search">
+    {{if .Writable}}<a href="/person/new">Add person</a>{{end}}
   </form>
+  {{with .Error}}
+    <p class="error">{{.}}</p>
+  {{end}}
   <hr>
   <table border=1>
     <tr>
@@ -63,7 +79,7 @@ var (
     {{with $top := .}}
     {{range .Results}}
     <tr>
-      <td>{{.Name}}</td>
+      <td><a href="{{$top.PersonLink .}}">{{.Name}}</a></td>
       <td>{{$top.BirthdayStr .}}</td>
       <td>{{$top.InYearsStr .}}</td>
       <td>{{$top.InMonthsStr .}}</td>
@@ -77,30 +93,65 @@ var (
 </html>`
 )
 
+var (
+	kStatusMessages = map[string]string{
+		"added":   "Person added.",
+		"updated": "Person updated.",
+		"deleted": "Person deleted.",
+		"failed":  "Could not update birthday file. Please try again.",
+		"gone":    "That person no longer exists.",
+	}
+)
+
 var (
 	kTemplate *template.Template
 )
 
 type Handler struct {
 	Store birthday.Store
+
+	// If true, the page links to the form for adding a person.
+	Writable bool
+
 	Clock date_util.Clock
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
-	var entries []*birthday.Entry
-	err := h.Store.Read(
-		consume2.Filter(
-			consume2.AppendPtrsTo(&entries),
-			birthday.Query(r.Form.Get("q"))))
+	w.Header().Add("Vary", "Accept")
+	format, err := common.NegotiateFormat(r)
+	if err != nil {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
 	if err != nil {
 		fmt.Fprintln(w, err)
 		return
 	}
+	entries = birthday.EntriesSortedByName(entries)
+	currentDate, err := dateparse.ParseDate(h.Clock, r.Form.Get("date"))
+	if err != nil {
+		if format != common.HTML {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		http_util.WriteTemplate(w, kTemplate, &view{
+			Values:   http_util.Values{Values: r.Form},
+			Writable: h.Writable,
+			Error:    err.Error(),
+		})
+		return
+	}
+	if common.WriteEntries(w, format, entries, currentDate) {
+		return
+	}
 	http_util.WriteTemplate(w, kTemplate, &view{
 		Values:      http_util.Values{Values: r.Form},
-		Results:     birthday.EntriesSortedByName(entries),
-		CurrentDate: common.ParseDate(h.Clock, r.Form.Get("date")),
+		Results:     entries,
+		CurrentDate: currentDate,
+		Writable:    h.Writable,
 	})
 }
 
@@ -108,6 +159,16 @@ type view struct {
 	http_util.Values
 	Results     []*birthday.Entry
 	CurrentDate time.Time
+	Writable    bool
+	Error       string
+}
+
+func (v *view) StatusMessage() string {
+	return kStatusMessages[v.Get("status")]
+}
+
+func (v *view) PersonLink(entry *birthday.Entry) *url.URL {
+	return common.PersonLink(entry.Id)
 }
 
 func (b *view) BirthdayStr(entry *birthday.Entry) string {
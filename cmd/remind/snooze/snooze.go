@@ -0,0 +1,217 @@
+// Package snooze handles the rules that hide people and milestones.
+package snooze
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+const (
+	// XsrfAction is the action of the xsrf tokens that forms posting to
+	// /mute must include.
+	XsrfAction = "/mute"
+
+	// XsrfExpire is how long xsrf tokens for /mute last.
+	XsrfExpire = time.Hour
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Muted</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  th {
+    font-size: 30px;
+  }
+  td {
+    font-size: 30px;
+  }
+  p {
+    font-size: 30px;
+  }
+  </style>
+</head>
+<body>
+  <h1>Muted</h1>
+  <p><a href="/home">Home</a></p>
+  {{if .Rows}}
+  <table border=1>
+    <tr>
+      <th>Name</th>
+      <th>Milestone</th>
+      <th>Until</th>
+      <th>&nbsp;</th>
+    </tr>
+    {{with $top := .}}
+    {{range .Rows}}
+    <tr>
+      <td><a href="{{.PersonLink}}">{{.Name}}</a></td>
+      <td>{{.Milestone}}</td>
+      <td>{{.Until}}</td>
+      <td>
+        <form method="post" action="/mute">
+          <input type="hidden" name="xsrf" value="{{$top.Xsrf}}">
+          <input type="hidden" name="return" value="/mute">
+          <input type="hidden" name="remove" value="1">
+          {{if .Rule.EntryId}}
+          <input type="hidden" name="id" value="{{.Rule.EntryId}}">
+          {{else}}
+          <input type="hidden" name="key" value="{{.Rule.Key}}">
+          {{end}}
+          <input type="submit" value="Unmute">
+        </form>
+      </td>
+    </tr>
+    {{end}}
+    {{end}}
+  </table>
+  {{else}}
+  <p>Nothing muted. Mute people and hide milestones from their pages.</p>
+  {{end}}
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+// Handler serves /mute. GET requests list the rules. POST requests
+// mute the person given by the id parameter or hide the milestone given
+// by the key parameter until the date in the until parameter or for
+// good if until is empty. With a remove parameter, Handler removes the
+// rule instead. After a POST, Handler redirects to the page in the
+// return parameter, /home by default.
+type Handler struct {
+	Store birthday.Store
+	Rules *mute.Rules
+	Xsrf  *common.Xsrf
+	Clock date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.Method == http.MethodPost {
+		h.doPost(w, r)
+		return
+	}
+	entries, err := common.ReadEntries(h.Store, "")
+	if err != nil {
+		http_util.ReportError(w, "Error reading birthday file", err)
+		return
+	}
+	byId := make(map[string]*birthday.Entry, len(entries))
+	for _, entry := range entries {
+		byId[entry.Id] = entry
+	}
+	var rows []*row
+	for _, rule := range h.Rules.List() {
+		rows = append(rows, newRow(rule, byId))
+	}
+	http_util.WriteTemplate(w, kTemplate, &view{
+		Rows: rows,
+		Xsrf: h.Xsrf.NewToken(XsrfAction, h.Clock.Now().Add(XsrfExpire)),
+	})
+}
+
+func (h *Handler) doPost(w http.ResponseWriter, r *http.Request) {
+	if !h.Xsrf.VerifyToken(r.Form.Get("xsrf"), XsrfAction, h.Clock.Now()) {
+		http.Error(
+			w,
+			"Form expired. Please go back, reload and try again.",
+			http.StatusForbidden)
+		return
+	}
+	rule := mute.Rule{EntryId: r.Form.Get("id"), Key: r.Form.Get("key")}
+	if (rule.EntryId == "") == (rule.Key == "") {
+		http_util.Error(w, http.StatusBadRequest)
+		return
+	}
+	if rule.Key != "" {
+		if _, _, _, err := birthday.ParseMilestoneKey(rule.Key); err != nil {
+			http_util.Error(w, http.StatusBadRequest)
+			return
+		}
+	}
+	var err error
+	if http_util.HasParam(r.Form, "remove") {
+		err = h.Rules.Remove(rule)
+	} else {
+		if untilStr := r.Form.Get("until"); untilStr != "" {
+			rule.Until, err = dateparse.ParseDate(h.Clock, untilStr)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		err = h.Rules.Add(rule)
+	}
+	if err != nil {
+		http_util.ReportError(w, "Error saving mute rules", err)
+		return
+	}
+	http_util.Redirect(w, r, common.ReturnPath(r.Form.Get("return")))
+}
+
+type view struct {
+	Rows []*row
+	Xsrf string
+}
+
+type row struct {
+	Rule      mute.Rule
+	Name      string
+	Milestone string
+	entryId   string
+}
+
+// newRow returns the row for rule. byId maps ids to entries. If the
+// person rule refers to is gone, the row shows the id instead of a name.
+func newRow(rule mute.Rule, byId map[string]*birthday.Entry) *row {
+	result := &row{Rule: rule, entryId: rule.EntryId, Milestone: "All"}
+	if rule.Key != "" {
+		entryId, period, count, _ := birthday.ParseMilestoneKey(rule.Key)
+		result.entryId = entryId
+		result.Milestone = rule.Key
+		if entry, ok := byId[entryId]; ok {
+			milestone, ok := birthday.MilestoneAt(entry, period, count)
+			if ok {
+				result.Milestone = birthday.ToStringWithWeekDay(
+					milestone.Date) + " " + milestone.AgeString()
+			}
+		}
+	}
+	result.Name = result.entryId
+	if entry, ok := byId[result.entryId]; ok {
+		result.Name = entry.Name
+	}
+	return result
+}
+
+func (r *row) PersonLink() *url.URL {
+	return common.PersonLink(r.entryId)
+}
+
+func (r *row) Until() string {
+	if r.Rule.Forever() {
+		return "Forever"
+	}
+	return birthday.ToString(r.Rule.Until)
+}
+
+func init() {
+	kTemplate = common.NewTemplate("snooze", kTemplateSpec)
+}
@@ -0,0 +1,229 @@
+package year
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/cmd/remind/common"
+	"github.com/keep94/birthday/grid"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/toolbox/date_util"
+	"github.com/keep94/toolbox/http_util"
+)
+
+var (
+	kTemplateSpec = `
+<html>
+<head>
+  <title>Birthdays {{.Year.Year}}</title>
+  <style>
+  h1 {
+    font-size: 40px;
+  }
+  h2 {
+    font-size: 20px;
+    margin: 4px;
+    text-align: center;
+  }
+  table.months {
+    width: 100%;
+  }
+  table.months > tbody > tr > td {
+    vertical-align: top;
+    width: 25%;
+  }
+  table.month {
+    border-collapse: collapse;
+    width: 100%;
+  }
+  table.month th {
+    font-size: 12px;
+  }
+  table.month td {
+    border: 1px solid gray;
+    font-size: 9px;
+    height: 60px;
+    vertical-align: top;
+    width: 14%;
+  }
+  table.month td.today {
+    background-color: lightyellow;
+  }
+  span.day {
+    font-size: 11px;
+    font-weight: bold;
+  }
+  @page {
+    size: landscape;
+    margin: 1cm;
+  }
+  @media print {
+    p.nav {
+      display: none;
+    }
+    h1 {
+      font-size: 24px;
+      margin: 0;
+      text-align: center;
+    }
+    a {
+      color: black;
+      text-decoration: none;
+    }
+    table.month td {
+      height: 48px;
+    }
+    table.month td.today {
+      background-color: transparent;
+    }
+  }
+  </style>
+</head>
+<body>
+  <h1>Birthdays {{.Year.Year}}</h1>
+  <p class="nav">
+    <a href="{{.PrevLink}}">&lt; {{.Prev}}</a>
+    <a href="{{.NextLink}}">{{.Next}} &gt;</a>
+    <a href="{{.SVGLink}}">Download SVG</a>
+    <a href="/home">Home</a>
+  </p>
+  {{with $top := .}}
+  <table class="months">
+    {{range .Rows}}
+    <tr>
+      {{range .}}
+      <td>
+        <h2>{{.First.Month}}</h2>
+        <table class="month">
+          <tr>
+            {{range $top.Weekdays}}
+            <th>{{.}}</th>
+            {{end}}
+          </tr>
+          {{range .Weeks}}
+          <tr>
+            {{range .}}
+            <td {{if $top.Today .}}class="today"{{end}}>
+              {{if .InMonth}}
+              <span class="day">{{.Date.Day}}</span>
+              {{range .Milestones}}
+              <br><a href="{{$top.PersonLink .}}">{{.EntryPtr.Name}}</a> {{.AgeString}}
+              {{end}}
+              {{end}}
+            </td>
+            {{end}}
+          </tr>
+          {{end}}
+        </table>
+      </td>
+      {{end}}
+    </tr>
+    {{end}}
+  </table>
+  {{end}}
+</body>
+</html>`
+)
+
+var (
+	kTemplate *template.Template
+)
+
+const (
+	kMonthsPerRow = 4
+)
+
+// Handler serves /year, all the milestones of one year laid out as
+// twelve months. The year parameter selects the year and defaults to the
+// current year. Handler honors the same q and p parameters as /home.
+// With format=svg, Handler serves the year as an SVG image suitable for
+// printing.
+type Handler struct {
+	Store          birthday.Store
+	DefaultPeriods []birthday.Period
+	Mute           *mute.Rules
+	Clock          date_util.Clock
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	entries, err := common.ReadEntries(h.Store, r.Form.Get("q"))
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return
+	}
+	today := birthday.Today(h.Clock)
+	yearNo := today.Year()
+	if yearStr := r.Form.Get("year"); yearStr != "" {
+		yearNo, err = strconv.Atoi(yearStr)
+		if err != nil || yearNo < 1 || yearNo > 9999 {
+			http_util.Error(w, http.StatusBadRequest)
+			return
+		}
+	}
+	year := grid.NewYearOf(
+		yearNo,
+		h.Mute.Filter(birthday.RemindPtrs(
+			entries,
+			common.ParsePeriods(r.Form.Get("p"), h.DefaultPeriods),
+			date_util.YMD(yearNo, 1, 1))))
+	if r.Form.Get("format") == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set(
+			"Content-Disposition",
+			fmt.Sprintf(`attachment; filename="birthdays-%d.svg"`, yearNo))
+		year.WriteSVG(w)
+		return
+	}
+	var rows [][]*grid.Month
+	for i := 0; i < len(year.Months); i += kMonthsPerRow {
+		rows = append(rows, year.Months[i:i+kMonthsPerRow])
+	}
+	http_util.WriteTemplate(w, kTemplate, &view{
+		Year:     year,
+		Rows:     rows,
+		Prev:     yearNo - 1,
+		Next:     yearNo + 1,
+		PrevLink: http_util.WithParams(r.URL, "year", strconv.Itoa(yearNo-1)),
+		NextLink: http_util.WithParams(r.URL, "year", strconv.Itoa(yearNo+1)),
+		SVGLink: http_util.WithParams(
+			r.URL, "year", strconv.Itoa(yearNo), "format", "svg"),
+		today: today,
+	})
+}
+
+type view struct {
+	Year     *grid.Year
+	Rows     [][]*grid.Month
+	Prev     int
+	Next     int
+	PrevLink *url.URL
+	NextLink *url.URL
+	SVGLink  *url.URL
+	today    time.Time
+}
+
+func (v *view) Weekdays() []string {
+	var result []string
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		result = append(result, weekday.String()[:2])
+	}
+	return result
+}
+
+func (v *view) Today(d *grid.Day) bool {
+	return d.InMonth && d.Date.Equal(v.today)
+}
+
+func (v *view) PersonLink(milestone *birthday.Milestone) *url.URL {
+	return common.PersonLink(milestone.EntryPtr.Id)
+}
+
+func init() {
+	kTemplate = common.NewTemplate("year", kTemplateSpec)
+}
@@ -3,11 +3,17 @@ package main
 import (
 	"flag"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"time"
 
 	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/birthday/grid"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/birthday/internal/milestonetext"
+	"github.com/keep94/birthday/mute"
 	"github.com/keep94/consume2"
 	"github.com/keep94/itertools"
 	"github.com/keep94/toolbox/date_util"
@@ -20,6 +26,10 @@ const (
 var (
 	fFile      string
 	fDaysAhead int
+	fFormat    string
+	fYear      int
+	fDate      string
+	fMuteFile  string
 )
 
 var (
@@ -34,36 +44,99 @@ func main() {
 		os.Exit(1)
 	}
 	var entries []*birthday.Entry
-	err := birthday.ReadFile(fFile, consume2.AppendPtrsTo(&entries))
+	err := ical.NewStore(fFile).Read(consume2.AppendPtrsTo(&entries))
 	if err != nil {
 		log.Fatal(err)
 	}
-	today := birthday.Today(kClock)
-	endTime := today.AddDate(0, 0, fDaysAhead)
-	seq := birthday.RemindPtrs(entries, birthday.DefaultPeriods, today)
-	seq = itertools.TakeWhile(
-		func(m *birthday.Milestone) bool { return m.Date.Before(endTime) },
-		seq)
-	seq = itertools.Take(kMaxRows, seq)
-	for milestonePtr := range seq {
-		printMilestone(milestonePtr, today)
+	mutePath := fMuteFile
+	if mutePath == "" {
+		mutePath = fFile + ".mute"
 	}
-}
-
-func printMilestone(milestone *birthday.Milestone, today time.Time) {
-	astricks := " "
-	if milestone.Date.Equal(today) {
-		astricks = "*"
+	rules, err := mute.Open(mutePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	today, err := dateparse.ParseDate(kClock, fDate)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	var seq iter.Seq[*birthday.Milestone]
+	if fYear != 0 {
+		start := date_util.YMD(fYear, 1, 1)
+		end := date_util.YMD(fYear+1, 1, 1)
+		seq = rules.Filter(
+			birthday.RemindPtrs(entries, birthday.DefaultPeriods, start))
+		seq = itertools.TakeWhile(
+			func(m *birthday.Milestone) bool { return m.Date.Before(end) },
+			seq)
+	} else {
+		endTime := today.AddDate(0, 0, fDaysAhead)
+		seq = rules.Filter(
+			birthday.RemindPtrs(entries, birthday.DefaultPeriods, today))
+		seq = itertools.TakeWhile(
+			func(m *birthday.Milestone) bool { return m.Date.Before(endTime) },
+			seq)
+		seq = itertools.Take(kMaxRows, seq)
+	}
+	switch fFormat {
+	case "text":
+		month := time.Month(0)
+		for milestonePtr := range seq {
+			if fYear != 0 && milestonePtr.Date.Month() != month {
+				if month != 0 {
+					fmt.Println()
+				}
+				month = milestonePtr.Date.Month()
+				fmt.Printf("%s %d\n", month, fYear)
+			}
+			milestonetext.Write(os.Stdout, milestonePtr, today)
+		}
+	case "ics":
+		calendar := &ical.Calendar{
+			Name:   "Birthdays",
+			Domain: ical.DefaultDomain,
+			Stamp:  kClock.Now(),
+		}
+		if err := calendar.Write(os.Stdout, seq); err != nil {
+			log.Fatal(err)
+		}
+	case "svg":
+		yearNo := fYear
+		if yearNo == 0 {
+			yearNo = today.Year()
+		}
+		year := grid.NewYearOf(
+			yearNo,
+			rules.Filter(birthday.RemindPtrs(
+				entries,
+				birthday.DefaultPeriods,
+				date_util.YMD(yearNo, 1, 1))))
+		if err := year.WriteSVG(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		fmt.Printf("Unknown format: %s\n", fFormat)
+		flag.Usage()
+		os.Exit(1)
 	}
-	fmt.Printf(
-		"%s %14s %20s %s\n",
-		astricks,
-		birthday.ToStringWithWeekDay(milestone.Date),
-		milestone.AgeString(),
-		milestone.EntryPtr.Name)
 }
 
 func init() {
 	flag.StringVar(&fFile, "file", "", "Birthday file")
 	flag.IntVar(&fDaysAhead, "days_ahead", 21, "Days ahead")
+	flag.StringVar(
+		&fFormat, "format", "text", "Output format: text, ics or svg")
+	flag.IntVar(
+		&fYear, "year", 0, "Show all special days of this year instead")
+	flag.StringVar(
+		&fDate,
+		"date",
+		"",
+		"Start date e.g 12/28, yesterday, next friday or +10d. Default today")
+	flag.StringVar(
+		&fMuteFile,
+		"mute_file",
+		"",
+		"File of muted people and milestones. Default is birthday file + .mute")
 }
@@ -0,0 +1,281 @@
+// Package dateparse parses dates written the way people write them such
+// as "yesterday", "next friday", "+10d", "2024-12-28" and "Dec 28".
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/toolbox/date_util"
+)
+
+var (
+	kRelative = regexp.MustCompile(`^([+-])(\d+)\s*([a-z]+)$`)
+	kIn       = regexp.MustCompile(`^in (\d+) ([a-z]+)$`)
+	kAgo      = regexp.MustCompile(`^(\d+) ([a-z]+) ago$`)
+	kISO      = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}$`)
+	kOrdinal  = regexp.MustCompile(`^(\d+)(?:st|nd|rd|th)$`)
+)
+
+var (
+	kUnits = map[string]string{
+		"d":      "d",
+		"day":    "d",
+		"days":   "d",
+		"w":      "w",
+		"week":   "w",
+		"weeks":  "w",
+		"m":      "m",
+		"month":  "m",
+		"months": "m",
+		"y":      "y",
+		"year":   "y",
+		"years":  "y",
+	}
+	kWeekdays = make(map[string]time.Weekday)
+	kMonths   = make(map[string]time.Month)
+)
+
+// Parse parses s relative to today, a date in UTC. Parse accepts
+//
+//   - today, yesterday and tomorrow
+//   - a weekday such as friday which is the next friday on or after
+//     today, next friday which is the first friday after today, and
+//     last friday which is the last friday before today
+//   - an offset from today such as +10d, -3w, +2m or +1y where d, w, m
+//     and y stand for days, weeks, months and years. "in 10 days" and
+//     "3 weeks ago" also work.
+//   - 2024-12-28, 12/28/2024 or 12/28
+//   - a month name with or without a day and year such as Dec 28,
+//     December 28, 2024, 28 December 2024 or December.
+//
+// Parse is case insensitive. Like birthday.Parse, Parse returns a time
+// in year 0 when s names a month and day without a year. A month without
+// a day means the first of that month. Parse returns an error if it
+// cannot understand s.
+func Parse(s string, today time.Time) (time.Time, error) {
+	normalized := strings.Join(
+		strings.Fields(strings.ToLower(strings.ReplaceAll(s, ",", " "))), " ")
+	result, ok := parse(normalized, today)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cannot understand date %q", s)
+	}
+	return result, nil
+}
+
+// ParseDate parses s to a date in UTC relative to the current date
+// of clock. s can be anything Parse understands. Unlike Parse, if s has
+// no year, the current year is used. If s is empty, ParseDate returns
+// the current date. ParseDate returns an error suitable for showing to
+// the user if it cannot understand s.
+func ParseDate(clock date_util.Clock, s string) (time.Time, error) {
+	result, _, err := parseDate(clock, s)
+	return result, err
+}
+
+// ParseRange returns the start and end of the range of dates from
+// fromStr through toStr inclusive. fromStr and toStr are parsed like
+// ParseDate. end is the day after toStr. If toStr is empty, the range
+// covers days days. If toStr has no year and would come before fromStr,
+// it is taken to be in the following year so that a range like 12/20 to
+// 1/5 spans the new year.
+func ParseRange(
+	clock date_util.Clock,
+	fromStr, toStr string,
+	days int) (start, end time.Time, err error) {
+	start, err = ParseDate(clock, fromStr)
+	if err != nil {
+		return
+	}
+	if toStr == "" {
+		return start, start.AddDate(0, 0, days), nil
+	}
+	to, hasYear, err := parseDate(clock, toStr)
+	if err != nil {
+		return
+	}
+	if !hasYear && to.Before(start) {
+		to = to.AddDate(1, 0, 0)
+	}
+	return start, to.AddDate(0, 0, 1), nil
+}
+
+// parseDate parses s like ParseDate. hasYear is false if s had no year.
+func parseDate(clock date_util.Clock, s string) (
+	result time.Time, hasYear bool, err error) {
+	today := birthday.Today(clock)
+	if s == "" {
+		return today, true, nil
+	}
+	result, err = Parse(s, today)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return fixMissingYear(today, result), birthday.HasYear(result), nil
+}
+
+func fixMissingYear(today, date time.Time) time.Time {
+	if birthday.HasYear(date) {
+		return date
+	}
+	return date_util.YMD(today.Year(), int(date.Month()), date.Day())
+}
+
+func parse(s string, today time.Time) (time.Time, bool) {
+	switch s {
+	case "today", "now":
+		return today, true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	}
+	if match := kRelative.FindStringSubmatch(s); match != nil {
+		count, ok := parseCount(match[2])
+		if match[1] == "-" {
+			count = -count
+		}
+		return offset(today, count, match[3], ok)
+	}
+	if match := kIn.FindStringSubmatch(s); match != nil {
+		count, ok := parseCount(match[1])
+		return offset(today, count, match[2], ok)
+	}
+	if match := kAgo.FindStringSubmatch(s); match != nil {
+		count, ok := parseCount(match[1])
+		return offset(today, -count, match[2], ok)
+	}
+	if result, ok := parseWeekday(s, today); ok {
+		return result, true
+	}
+	if kISO.MatchString(s) {
+		result, err := time.Parse("2006-1-2", s)
+		return result, err == nil
+	}
+	if strings.Contains(s, "/") {
+		result, err := birthday.Parse(s)
+		return result, err == nil
+	}
+	return parseMonthDayYear(strings.Split(s, " "))
+}
+
+func parseCount(s string) (int, bool) {
+	result, err := strconv.Atoi(s)
+	return result, err == nil
+}
+
+func offset(today time.Time, count int, unit string, ok bool) (
+	time.Time, bool) {
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kUnits[unit] {
+	case "d":
+		return today.AddDate(0, 0, count), true
+	case "w":
+		return today.AddDate(0, 0, 7*count), true
+	case "m":
+		return today.AddDate(0, count, 0), true
+	case "y":
+		return today.AddDate(count, 0, 0), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func parseWeekday(s string, today time.Time) (time.Time, bool) {
+	words := strings.Split(s, " ")
+	var modifier string
+	if len(words) == 2 {
+		modifier, words = words[0], words[1:]
+	}
+	if len(words) != 1 {
+		return time.Time{}, false
+	}
+	weekday, ok := kWeekdays[words[0]]
+	if !ok {
+		return time.Time{}, false
+	}
+	diff := (int(weekday) - int(today.Weekday()) + 7) % 7
+	switch modifier {
+	case "", "this":
+		return today.AddDate(0, 0, diff), true
+	case "next":
+		if diff == 0 {
+			diff = 7
+		}
+		return today.AddDate(0, 0, diff), true
+	case "last":
+		back := (int(today.Weekday()) - int(weekday) + 7) % 7
+		if back == 0 {
+			back = 7
+		}
+		return today.AddDate(0, 0, -back), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// parseMonthDayYear parses forms like "dec 28 2024", "28 december" and
+// "december".
+func parseMonthDayYear(words []string) (time.Time, bool) {
+	if len(words) == 0 || len(words) > 3 {
+		return time.Time{}, false
+	}
+	var month time.Month
+	var numbers []string
+	for _, word := range words {
+		if m, ok := kMonths[word]; ok && month == 0 {
+			month = m
+			continue
+		}
+		if match := kOrdinal.FindStringSubmatch(word); match != nil {
+			word = match[1]
+		}
+		numbers = append(numbers, word)
+	}
+	if month == 0 {
+		return time.Time{}, false
+	}
+	day, year := 1, 0
+	var ok bool
+	switch len(numbers) {
+	case 0:
+	case 1:
+		if day, ok = parseCount(numbers[0]); !ok {
+			return time.Time{}, false
+		}
+	case 2:
+		if day, ok = parseCount(numbers[0]); !ok {
+			return time.Time{}, false
+		}
+		if year, ok = parseCount(numbers[1]); !ok || year < 1 {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	result := date_util.YMD(year, int(month), day)
+	if day < 1 || result.Day() != day {
+		return time.Time{}, false
+	}
+	return result, true
+}
+
+func init() {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		kWeekdays[name] = weekday
+		kWeekdays[name[:3]] = weekday
+	}
+	for month := time.January; month <= time.December; month++ {
+		name := strings.ToLower(month.String())
+		kMonths[name] = month
+		kMonths[name[:3]] = month
+	}
+	kMonths["sept"] = time.September
+}
@@ -0,0 +1,124 @@
+package dateparse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/dateparse"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+var (
+	// A Wednesday
+	kToday = date_util.YMD(2024, 12, 18)
+)
+
+func TestParse(t *testing.T) {
+	assert := asserts.New(t)
+	cases := []struct {
+		s    string
+		want time.Time
+	}{
+		{"today", kToday},
+		{" Yesterday ", date_util.YMD(2024, 12, 17)},
+		{"tomorrow", date_util.YMD(2024, 12, 19)},
+		{"friday", date_util.YMD(2024, 12, 20)},
+		{"wednesday", kToday},
+		{"this wed", kToday},
+		{"next friday", date_util.YMD(2024, 12, 20)},
+		{"next Wednesday", date_util.YMD(2024, 12, 25)},
+		{"last friday", date_util.YMD(2024, 12, 13)},
+		{"last wednesday", date_util.YMD(2024, 12, 11)},
+		{"+10d", date_util.YMD(2024, 12, 28)},
+		{"-3d", date_util.YMD(2024, 12, 15)},
+		{"+2w", date_util.YMD(2025, 1, 1)},
+		{"+1m", date_util.YMD(2025, 1, 18)},
+		{"-1y", date_util.YMD(2023, 12, 18)},
+		{"in 3 days", date_util.YMD(2024, 12, 21)},
+		{"2 weeks ago", date_util.YMD(2024, 12, 4)},
+		{"2024-12-28", date_util.YMD(2024, 12, 28)},
+		{"2025-1-5", date_util.YMD(2025, 1, 5)},
+		{"12/28/2024", date_util.YMD(2024, 12, 28)},
+		{"12/28", date_util.YMD(0, 12, 28)},
+		{"Dec 28", date_util.YMD(0, 12, 28)},
+		{"December 28, 2024", date_util.YMD(2024, 12, 28)},
+		{"28 december 2024", date_util.YMD(2024, 12, 28)},
+		{"March 1st", date_util.YMD(0, 3, 1)},
+		{"sept 3", date_util.YMD(0, 9, 3)},
+		{"february", date_util.YMD(0, 2, 1)},
+		{"Feb 29 2024", date_util.YMD(2024, 2, 29)},
+	}
+	for _, c := range cases {
+		got, err := dateparse.Parse(c.s, kToday)
+		if assert.NoError(err, c.s) {
+			assert.Equal(c.want, got, c.s)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	assert := asserts.New(t)
+	for _, s := range []string{
+		"",
+		"someday",
+		"next",
+		"next month",
+		"+10q",
+		"+d",
+		"2024-13-01",
+		"13/01",
+		"Feb 30",
+		"Feb 29 2023",
+		"Dec 0",
+		"Dec 28 2024 extra",
+		"28",
+		"friday 13",
+	} {
+		_, err := dateparse.Parse(s, kToday)
+		assert.Error(err, s)
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	assert := asserts.New(t)
+	_, err := dateparse.Parse("someday", kToday)
+	assert.EqualError(err, `cannot understand date "someday"`)
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestParseDate(t *testing.T) {
+	assert := asserts.New(t)
+	clock := fixedClock(time.Date(2024, 12, 18, 15, 0, 0, 0, time.UTC))
+	date, err := dateparse.ParseDate(clock, "")
+	assert.NoError(err)
+	assert.Equal(kToday, date)
+	date, err = dateparse.ParseDate(clock, "Mar 5")
+	assert.NoError(err)
+	assert.Equal(date_util.YMD(2024, 3, 5), date)
+	_, err = dateparse.ParseDate(clock, "someday")
+	assert.Error(err)
+}
+
+func TestParseRange(t *testing.T) {
+	assert := asserts.New(t)
+	clock := fixedClock(time.Date(2024, 12, 18, 15, 0, 0, 0, time.UTC))
+	start, end, err := dateparse.ParseRange(clock, "", "", 10)
+	assert.NoError(err)
+	assert.Equal(kToday, start)
+	assert.Equal(date_util.YMD(2024, 12, 28), end)
+
+	// A to date without a year before from falls in the next year.
+	start, end, err = dateparse.ParseRange(clock, "12/20", "1/5", 10)
+	assert.NoError(err)
+	assert.Equal(date_util.YMD(2024, 12, 20), start)
+	assert.Equal(date_util.YMD(2025, 1, 6), end)
+
+	_, _, err = dateparse.ParseRange(clock, "12/20", "someday", 10)
+	assert.Error(err)
+}
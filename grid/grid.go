@@ -0,0 +1,118 @@
+// Package grid lays out birthday milestones as calendar grids of months
+// and years and draws a year as a self-contained SVG image.
+package grid
+
+import (
+	"iter"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+)
+
+// Day is one cell of a month grid.
+type Day struct {
+	Date time.Time
+
+	// True if Date falls in the month of the grid.
+	InMonth bool
+
+	// The milestones falling on Date.
+	Milestones []*birthday.Milestone
+}
+
+// Month is the grid of one month. Each week runs Sunday through Saturday.
+// The first week starts on the Sunday on or before the first of the
+// month; the last week ends on the Saturday on or after the last of the
+// month.
+type Month struct {
+
+	// The first of the month
+	First time.Time
+
+	Weeks [][]*Day
+}
+
+// NewMonth returns an empty grid for the month containing date.
+func NewMonth(date time.Time) *Month {
+	first := date_util.YMD(date.Year(), int(date.Month()), 1)
+	next := first.AddDate(0, 1, 0)
+	result := &Month{First: first}
+	end := result.End()
+	for day := result.Start(); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Sunday {
+			result.Weeks = append(result.Weeks, nil)
+		}
+		last := len(result.Weeks) - 1
+		result.Weeks[last] = append(result.Weeks[last], &Day{
+			Date:    day,
+			InMonth: !day.Before(first) && day.Before(next),
+		})
+	}
+	return result
+}
+
+// Start returns the first date in the grid.
+func (m *Month) Start() time.Time {
+	return m.First.AddDate(0, 0, -int(m.First.Weekday()))
+}
+
+// End returns the day after the last date in the grid.
+func (m *Month) End() time.Time {
+	next := m.First.AddDate(0, 1, 0)
+	return next.AddDate(0, 0, (7-int(next.Weekday()))%7)
+}
+
+// Day returns the cell for date. If date is not in the grid, Day returns
+// nil.
+func (m *Month) Day(date time.Time) *Day {
+	start := m.Start()
+	if date.Before(start) || !date.Before(m.End()) {
+		return nil
+	}
+	offset := int(date.Sub(start).Hours()+12) / 24
+	return m.Weeks[offset/7][offset%7]
+}
+
+// Add adds each milestone in milestones to the cell of its date.
+// milestones must be in chronological order. Add ignores milestones
+// before the grid and stops at the first milestone after it.
+func (m *Month) Add(milestones iter.Seq[*birthday.Milestone]) {
+	end := m.End()
+	for milestone := range milestones {
+		if !milestone.Date.Before(end) {
+			return
+		}
+		if day := m.Day(milestone.Date); day != nil {
+			day.Milestones = append(day.Milestones, milestone)
+		}
+	}
+}
+
+// Year is the twelve month grids of one year.
+type Year struct {
+	Year   int
+	Months []*Month
+}
+
+// NewYearOf returns the grids for year with the milestones in
+// milestones that fall in year. milestones must be in chronological
+// order and start no earlier than the first of the year. Cells of days
+// outside their month stay empty so that each milestone appears once.
+func NewYearOf(year int, milestones iter.Seq[*birthday.Milestone]) *Year {
+	result := &Year{Year: year}
+	for month := 1; month <= 12; month++ {
+		result.Months = append(
+			result.Months, NewMonth(date_util.YMD(year, month, 1)))
+	}
+	end := date_util.YMD(year+1, 1, 1)
+	milestones = itertools.TakeWhile(
+		func(m *birthday.Milestone) bool { return m.Date.Before(end) },
+		milestones)
+	for m := range milestones {
+		day := result.Months[m.Date.Month()-1].Day(m.Date)
+		day.Milestones = append(day.Milestones, m)
+	}
+	return result
+}
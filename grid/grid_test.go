@@ -0,0 +1,113 @@
+package grid_test
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/grid"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func TestNewMonth(t *testing.T) {
+	assert := asserts.New(t)
+	month := grid.NewMonth(date_util.YMD(2024, 2, 14))
+	assert.Equal(date_util.YMD(2024, 2, 1), month.First)
+	assert.Equal(date_util.YMD(2024, 1, 28), month.Start())
+	assert.Equal(date_util.YMD(2024, 3, 3), month.End())
+	assert.Len(month.Weeks, 5)
+	for _, week := range month.Weeks {
+		assert.Len(week, 7)
+	}
+	assert.Equal(date_util.YMD(2024, 1, 28), month.Weeks[0][0].Date)
+	assert.False(month.Weeks[0][0].InMonth)
+	assert.Equal(date_util.YMD(2024, 2, 1), month.Weeks[0][4].Date)
+	assert.True(month.Weeks[0][4].InMonth)
+	assert.Equal(date_util.YMD(2024, 3, 2), month.Weeks[4][6].Date)
+	assert.False(month.Weeks[4][6].InMonth)
+	assert.Same(month.Weeks[2][3], month.Day(date_util.YMD(2024, 2, 14)))
+	assert.Nil(month.Day(date_util.YMD(2024, 1, 27)))
+	assert.Nil(month.Day(date_util.YMD(2024, 3, 3)))
+}
+
+func TestNewMonthStartsOnSunday(t *testing.T) {
+	assert := asserts.New(t)
+	month := grid.NewMonth(date_util.YMD(2026, 2, 1))
+	assert.Equal(date_util.YMD(2026, 2, 1), month.Start())
+	assert.Equal(date_util.YMD(2026, 3, 1), month.End())
+	assert.Len(month.Weeks, 4)
+}
+
+func TestMonthAdd(t *testing.T) {
+	assert := asserts.New(t)
+	entry := &birthday.Entry{
+		Name: "Mark", Birthday: date_util.YMD(1990, 3, 1), Id: "mark"}
+	month := grid.NewMonth(date_util.YMD(2024, 2, 1))
+	month.Add(birthday.RemindPtrs(
+		[]*birthday.Entry{entry},
+		[]birthday.Period{{Years: 1}},
+		date_util.YMD(2024, 1, 1)))
+	day := month.Day(date_util.YMD(2024, 3, 1))
+	if assert.Len(day.Milestones, 1) {
+		assert.Equal(34, day.Milestones[0].Age.Years)
+	}
+}
+
+func TestNewYearOf(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Mark", Birthday: date_util.YMD(1990, 3, 1), Id: "mark"},
+		{Name: "Ann", Birthday: date_util.YMD(0, 12, 31), Id: "ann"},
+	}
+	year := grid.NewYearOf(
+		2024,
+		birthday.RemindPtrs(
+			entries, []birthday.Period{{Years: 1}}, date_util.YMD(2024, 1, 1)))
+	assert.Equal(2024, year.Year)
+	assert.Len(year.Months, 12)
+	var count int
+	for _, month := range year.Months {
+		for _, week := range month.Weeks {
+			for _, day := range week {
+				count += len(day.Milestones)
+				if !day.InMonth {
+					assert.Empty(day.Milestones)
+				}
+			}
+		}
+	}
+	assert.Equal(2, count)
+	assert.Len(year.Months[2].Day(date_util.YMD(2024, 3, 1)).Milestones, 1)
+	assert.Len(year.Months[11].Day(date_util.YMD(2024, 12, 31)).Milestones, 1)
+}
+
+func TestWriteSVG(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Tom & Jerry", Birthday: date_util.YMD(1990, 3, 1), Id: "tj"},
+	}
+	var sb strings.Builder
+	year := grid.NewYearOf(
+		2024,
+		birthday.RemindPtrs(
+			entries, []birthday.Period{{Years: 1}}, date_util.YMD(2024, 1, 1)))
+	assert.NoError(year.WriteSVG(&sb))
+	svg := sb.String()
+	assert.True(strings.HasPrefix(svg, "<svg "))
+	assert.Contains(svg, "Birthdays 2024")
+	assert.Contains(svg, "December")
+	assert.Contains(svg, "Tom &amp; Jerry 34 years")
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(err) {
+			break
+		}
+	}
+}
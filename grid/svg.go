@@ -0,0 +1,130 @@
+package grid
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	kColumns      = 4
+	kMonthWidth   = 400.0
+	kMonthHeight  = 380.0
+	kMargin       = 20.0
+	kTitleHeight  = 60.0
+	kMonthTitle   = 28.0
+	kWeekdayRow   = 18.0
+	kLineHeight   = 9.0
+	kFontSize     = 7.0
+	kMaxNameChars = 20
+
+	// Approximate width of one character of small text
+	kCharWidth = 0.55 * kFontSize
+)
+
+// WriteSVG draws this year to w as a self-contained SVG image with the
+// months in three rows of four. Each day lists as many milestones as fit
+// in its cell followed by a count of the ones that do not.
+func (y *Year) WriteSVG(w io.Writer) error {
+	rows := (len(y.Months) + kColumns - 1) / kColumns
+	width := kColumns*(kMonthWidth+kMargin) + kMargin
+	height := kTitleHeight + float64(rows)*(kMonthHeight+kMargin)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(
+		bw,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%.1f" height="%.1f" viewBox="0 0 %.1f %.1f" font-family="sans-serif">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(bw, `<rect width="%.1f" height="%.1f" fill="white"/>`+"\n", width, height)
+	fmt.Fprintf(
+		bw,
+		`<text x="%.1f" y="%.1f" font-size="36" text-anchor="middle">Birthdays %d</text>`+"\n",
+		width/2, kTitleHeight-18, y.Year)
+	for i, month := range y.Months {
+		x := kMargin + float64(i%kColumns)*(kMonthWidth+kMargin)
+		top := kTitleHeight + float64(i/kColumns)*(kMonthHeight+kMargin)
+		writeMonthSVG(bw, month, x, top)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func writeMonthSVG(w *bufio.Writer, month *Month, x, top float64) {
+	cellWidth := kMonthWidth / 7
+	gridTop := top + kMonthTitle + kWeekdayRow
+	cellHeight := (kMonthHeight - kMonthTitle - kWeekdayRow) / 6
+	fmt.Fprintf(
+		w,
+		`<text x="%.1f" y="%.1f" font-size="22" text-anchor="middle">%s</text>`+"\n",
+		x+kMonthWidth/2, top+22, month.First.Month())
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		fmt.Fprintf(
+			w,
+			`<text x="%.1f" y="%.1f" font-size="12" text-anchor="middle">%s</text>`+"\n",
+			x+(float64(weekday)+0.5)*cellWidth,
+			top+kMonthTitle+13,
+			weekday.String()[:3])
+	}
+	linesPerCell := int((cellHeight - 14) / kLineHeight)
+	for row, week := range month.Weeks {
+		for col, day := range week {
+			cx := x + float64(col)*cellWidth
+			cy := gridTop + float64(row)*cellHeight
+			fmt.Fprintf(
+				w,
+				`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="black" stroke-width="0.5"/>`+"\n",
+				cx, cy, cellWidth, cellHeight)
+			if !day.InMonth {
+				continue
+			}
+			fmt.Fprintf(
+				w,
+				`<text x="%.1f" y="%.1f" font-size="11" font-weight="bold">%d</text>`+"\n",
+				cx+3, cy+11, day.Date.Day())
+			shown := len(day.Milestones)
+			if shown > linesPerCell {
+				shown = linesPerCell - 1
+			}
+			for i := 0; i < shown; i++ {
+				m := day.Milestones[i]
+				writeLineSVG(
+					w,
+					cx+3,
+					cy+14+float64(i+1)*kLineHeight-2,
+					cellWidth-6,
+					truncate(m.EntryPtr.Name, kMaxNameChars)+" "+m.AgeString())
+			}
+			if more := len(day.Milestones) - shown; more > 0 {
+				writeLineSVG(
+					w,
+					cx+3,
+					cy+14+float64(shown+1)*kLineHeight-2,
+					cellWidth-6,
+					fmt.Sprintf("+%d more", more))
+			}
+		}
+	}
+}
+
+// writeLineSVG writes one line of small text squeezing it if needed to
+// fit in width.
+func writeLineSVG(w *bufio.Writer, x, y, width float64, text string) {
+	fmt.Fprintf(w, `<text x="%.1f" y="%.1f" font-size="%.1f"`, x, y, kFontSize)
+	if float64(len([]rune(text)))*kCharWidth > width {
+		fmt.Fprintf(
+			w, ` textLength="%.1f" lengthAdjust="spacingAndGlyphs"`, width)
+	}
+	fmt.Fprint(w, ">")
+	xml.EscapeText(w, []byte(text))
+	fmt.Fprintln(w, "</text>")
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,85 @@
+// Package ical writes birthday milestones in iCalendar format (RFC 5545).
+package ical
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/internal/contentline"
+)
+
+const (
+	// DefaultDomain is the default domain for UIDs.
+	DefaultDomain = "birthday.keep94.github.com"
+)
+
+const (
+	kProdId = "-//keep94//birthday//EN"
+)
+
+// Calendar writes milestones as an iCalendar VCALENDAR object.
+type Calendar struct {
+
+	// The name of the calendar shown by calendar apps. Optional.
+	Name string
+
+	// Used to make UIDs globally unique e.g "birthdays.example.com"
+	Domain string
+
+	// The DTSTAMP of each event.
+	Stamp time.Time
+}
+
+// Write writes milestones to w as a VCALENDAR object with one all-day
+// VEVENT per milestone.
+func (c *Calendar) Write(
+	w io.Writer, milestones iter.Seq[*birthday.Milestone]) error {
+	cw := contentline.NewWriter(w)
+	cw.Line("BEGIN", "VCALENDAR")
+	cw.Line("VERSION", "2.0")
+	cw.Line("PRODID", kProdId)
+	cw.Line("CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		cw.Line("X-WR-CALNAME", Escape(c.Name))
+	}
+	for m := range milestones {
+		c.writeEvent(cw, m)
+	}
+	cw.Line("END", "VCALENDAR")
+	return cw.Flush()
+}
+
+func (c *Calendar) writeEvent(cw *contentline.Writer, m *birthday.Milestone) {
+	cw.Line("BEGIN", "VEVENT")
+	cw.Line("UID", Escape(UID(m, c.Domain)))
+	cw.Line("DTSTAMP", c.Stamp.UTC().Format("20060102T150405Z"))
+	cw.Line("DTSTART;VALUE=DATE", m.Date.Format("20060102"))
+	cw.Line("DTEND;VALUE=DATE", m.Date.AddDate(0, 0, 1).Format("20060102"))
+	cw.Line("SUMMARY", Escape(Summary(m)))
+	cw.Line(
+		"DESCRIPTION",
+		Escape(fmt.Sprintf(
+			"Born %s", birthday.ToString(m.EntryPtr.Birthday))))
+	cw.Line("TRANSP", "TRANSPARENT")
+	cw.Line("END", "VEVENT")
+}
+
+// UID returns a UID for milestone that stays the same as long as the
+// entry Id, period and count of milestone stay the same.
+func UID(m *birthday.Milestone, domain string) string {
+	return m.Key() + "@" + domain
+}
+
+// Summary returns the summary of the event for milestone e.g
+// "John Smith: 57 years".
+func Summary(m *birthday.Milestone) string {
+	return fmt.Sprintf("%s: %s", m.EntryPtr.Name, m.AgeString())
+}
+
+// Escape escapes s for use as an iCalendar TEXT value.
+func Escape(s string) string {
+	return contentline.Escape(s)
+}
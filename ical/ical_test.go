@@ -0,0 +1,82 @@
+package ical_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	assert := asserts.New(t)
+	entries := []*birthday.Entry{
+		{Name: "Smith, John", Birthday: date_util.YMD(1967, 3, 25), Id: "john"},
+		{Name: "Merna", Birthday: date_util.YMD(0, 3, 26), Id: "merna"},
+	}
+	calendar := &ical.Calendar{
+		Name:   "Birthdays",
+		Domain: "example.com",
+		Stamp:  date_util.YMD(2024, 3, 1),
+	}
+	var sb strings.Builder
+	err := calendar.Write(
+		&sb,
+		itertools.Take(
+			2,
+			birthday.RemindPtrs(
+				entries,
+				[]birthday.Period{{Years: 1}},
+				date_util.YMD(2024, 3, 1))))
+	assert.NoError(err)
+	assert.Equal(
+		strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//keep94//birthday//EN",
+			"CALSCALE:GREGORIAN",
+			"X-WR-CALNAME:Birthdays",
+			"BEGIN:VEVENT",
+			"UID:john-1y0m0w0d-57@example.com",
+			"DTSTAMP:20240301T000000Z",
+			"DTSTART;VALUE=DATE:20240325",
+			"DTEND;VALUE=DATE:20240326",
+			`SUMMARY:Smith\, John: 57 years`,
+			"DESCRIPTION:Born 03/25/1967",
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:merna-1y0m0w0d-2024@example.com",
+			"DTSTAMP:20240301T000000Z",
+			"DTSTART;VALUE=DATE:20240326",
+			"DTEND;VALUE=DATE:20240327",
+			"SUMMARY:Merna: ? years",
+			"DESCRIPTION:Born 03/26",
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+			"END:VCALENDAR",
+			"",
+		}, "\r\n"),
+		sb.String())
+}
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	assert := asserts.New(t)
+	calendar := &ical.Calendar{Name: strings.Repeat("é", 50)}
+	var sb strings.Builder
+	assert.NoError(calendar.Write(&sb, itertools.Chain[*birthday.Milestone]()))
+	lines := strings.Split(sb.String(), "\r\n")
+	assert.Equal("X-WR-CALNAME:"+strings.Repeat("é", 31), lines[4])
+	assert.Equal(" "+strings.Repeat("é", 19), lines[5])
+	for _, line := range lines {
+		assert.LessOrEqual(len(line), 75)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	assert := asserts.New(t)
+	assert.Equal(`a\\b\;c\,d\ne`, ical.Escape("a\\b;c,d\ne"))
+}
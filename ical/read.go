@@ -0,0 +1,357 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+)
+
+const (
+	// XName is the property giving the name of the person whose
+	// birthday an event is. It overrides the name found in SUMMARY.
+	XName = "X-BIRTHDAY-NAME"
+
+	// XYear is the property giving the year of birth. It overrides the
+	// year found in SUMMARY.
+	XYear = "X-BIRTHDAY-YEAR"
+)
+
+var (
+	kYearInSummary = regexp.MustCompile(`^(.*?)\s*\((\d{4})\)$`)
+	kBirthdayWords = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^(.*?)(?:'s|’s|s'|s’)\s+birthday$`),
+		regexp.MustCompile(`(?i)^(.*?)\s+birthday$`),
+		regexp.MustCompile(`(?i)^birthday(?:\s+of|:|\s+-)?\s+(.*)$`),
+	}
+)
+
+// Skipped describes an event that could not be turned into an Entry.
+type Skipped struct {
+
+	// The line number where the event begins
+	Line int
+
+	// The summary of the event
+	Summary string
+
+	// Why the event was skipped
+	Reason string
+}
+
+func (s Skipped) String() string {
+	return fmt.Sprintf("Line %d: %q: %s", s.Line, s.Summary, s.Reason)
+}
+
+// NewStore returns the store for the birthday file at path. Files ending
+// in .ics are read-only iCalendar files whose skipped events are logged
+// with the standard logger once per change to the file. Other files are
+// birthday.SystemStore files.
+func NewStore(path string) birthday.Store {
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		return &FileStore{
+			Path: path,
+			Skipped: func(s Skipped) {
+				log.Printf("Skipped event: %s", s)
+			},
+		}
+	}
+	return birthday.SystemStore(path)
+}
+
+// FileStore reads birthdays from an iCalendar file.
+type FileStore struct {
+
+	// The path to the iCalendar file
+	Path string
+
+	// If non-nil, called for each event that could not be turned into
+	// an Entry. Skipped is called only the first time the file is read
+	// in full and then again only after the file changes.
+	Skipped func(Skipped)
+
+	mu       sync.Mutex
+	reported fileStamp
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Read reads the iCalendar file. consumer consumes the Entry instances
+// read.
+func (s *FileStore) Read(consumer consume2.Consumer[birthday.Entry]) error {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+	s.mu.Lock()
+	report := s.Skipped != nil && stamp != s.reported
+	s.mu.Unlock()
+	var skipped func(Skipped)
+	if report {
+		skipped = s.Skipped
+	}
+	if err := Read(file, consumer, skipped); err != nil {
+		return err
+	}
+
+	// If consumer can consume more, Read saw every event.
+	if report && consumer.CanConsume() {
+		s.mu.Lock()
+		s.reported = stamp
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Read reads the yearly VEVENTs in r as birthdays. consumer consumes the
+// Entry instances read. Read calls skipped, if non-nil, for each VEVENT
+// that it could not interpret. Each VEVENT must have an RRULE with
+// FREQ=YEARLY. The name comes from the X-BIRTHDAY-NAME property or from
+// a SUMMARY such as "John's birthday". The year of birth comes from
+// the X-BIRTHDAY-YEAR property or from a SUMMARY such as
+// "John's birthday (1967)". Otherwise the year of birth is unknown.
+// The month and day of birth come from DTSTART. Entries get an Id with
+// birthday.DeriveId. Read skips events that duplicate an earlier entry.
+func Read(
+	r io.Reader,
+	consumer consume2.Consumer[birthday.Entry],
+	skipped func(Skipped)) error {
+	if skipped == nil {
+		skipped = func(Skipped) {}
+	}
+	ids := make(map[string]bool)
+	return readEvents(r, func(e *event) bool {
+		entry, err := e.Entry()
+		if err == nil && ids[entry.Id] {
+			err = errors.New("duplicate entry")
+		}
+		if err != nil {
+			skipped(Skipped{
+				Line:    e.Line,
+				Summary: e.Get("SUMMARY"),
+				Reason:  err.Error(),
+			})
+			return true
+		}
+		ids[entry.Id] = true
+		consumer.Consume(entry)
+		return consumer.CanConsume()
+	})
+}
+
+// event is a VEVENT. Values are unescaped.
+type event struct {
+	Line       int
+	Properties map[string]string
+}
+
+func (e *event) Get(name string) string {
+	return e.Properties[name]
+}
+
+func (e *event) Has(name string) bool {
+	_, ok := e.Properties[name]
+	return ok
+}
+
+func (e *event) Entry() (birthday.Entry, error) {
+	if e.Has("RECURRENCE-ID") {
+		return birthday.Entry{}, errors.New("recurrence exception")
+	}
+	if !isYearly(e.Get("RRULE")) {
+		return birthday.Entry{}, errors.New("not a yearly event")
+	}
+	start, err := parseDate(e.Get("DTSTART"))
+	if err != nil {
+		return birthday.Entry{}, errors.New("invalid DTSTART")
+	}
+	name, year := parseSummary(e.Get("SUMMARY"))
+	if e.Has(XName) {
+		name = strings.TrimSpace(e.Get(XName))
+	}
+	if e.Has(XYear) {
+		year, err = strconv.Atoi(strings.TrimSpace(e.Get(XYear)))
+		if err != nil || year <= 0 {
+			return birthday.Entry{}, errors.New("invalid " + XYear)
+		}
+	}
+	if name == "" {
+		return birthday.Entry{}, errors.New("no name")
+	}
+	bday := date_util.YMD(year, int(start.Month()), start.Day())
+	if bday.Day() != start.Day() {
+		return birthday.Entry{}, errors.New("invalid birthday")
+	}
+	entry := birthday.Entry{Name: name, Birthday: bday}
+	entry.Id = birthday.DeriveId(entry)
+	return entry, nil
+}
+
+// isYearly returns true if rrule repeats every year.
+func isYearly(rrule string) bool {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(rrule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		parts[strings.ToUpper(key)] = strings.ToUpper(value)
+	}
+	if parts["FREQ"] != "YEARLY" {
+		return false
+	}
+	interval, ok := parts["INTERVAL"]
+	return !ok || interval == "1"
+}
+
+// parseDate parses a DATE or DATE-TIME value returning just the date.
+func parseDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, errors.New("too short")
+	}
+	return time.Parse("20060102", value[:8])
+}
+
+// parseSummary extracts the name and year of birth from summary.
+// If summary contains no year, the returned year is 0.
+func parseSummary(summary string) (name string, year int) {
+	name = strings.TrimSpace(summary)
+	if match := kYearInSummary.FindStringSubmatch(name); match != nil {
+		name = match[1]
+		year, _ = strconv.Atoi(match[2])
+	}
+	for _, re := range kBirthdayWords {
+		if match := re.FindStringSubmatch(name); match != nil {
+			name = match[1]
+			break
+		}
+	}
+	return strings.TrimSpace(name), year
+}
+
+// readEvents reads the VEVENTs in r calling f for each one until f
+// returns false. Properties of components nested in a VEVENT such as
+// VALARM are ignored.
+func readEvents(r io.Reader, f func(e *event) bool) error {
+	var current *event
+	depth := 0
+	return readContentLines(r, func(lineNo int, name, value string) error {
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") &&
+			current == nil:
+			current = &event{Line: lineNo, Properties: make(map[string]string)}
+		case name == "BEGIN" && current != nil:
+			depth++
+		case name == "END" && current != nil && depth > 0:
+			depth--
+		case name == "END" && current != nil:
+			e := current
+			current = nil
+			if !f(e) {
+				return errStop
+			}
+		case current != nil && depth == 0:
+			if _, ok := current.Properties[name]; !ok {
+				current.Properties[name] = unescape(value)
+			}
+		}
+		return nil
+	})
+}
+
+var errStop = errors.New("stop")
+
+// readContentLines reads the unfolded content lines in r calling f with
+// the line number, upper case property name without parameters, and raw
+// value of each one.
+func readContentLines(
+	r io.Reader, f func(lineNo int, name, value string) error) error {
+	scanner := bufio.NewScanner(r)
+	var line string
+	lineNo, startLineNo := 0, 0
+	flush := func() error {
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+		name, value, ok := splitContentLine(line)
+		if !ok {
+			return fmt.Errorf("Line %d malformatted", startLineNo)
+		}
+		return f(startLineNo, name, value)
+	}
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			line += text[1:]
+			continue
+		}
+		if err := flush(); err != nil {
+			if err == errStop {
+				return nil
+			}
+			return err
+		}
+		line, startLineNo = text, lineNo
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil && err != errStop {
+		return err
+	}
+	return nil
+}
+
+// splitContentLine splits line into its property name and value.
+// The returned name is in upper case and excludes any parameters.
+func splitContentLine(line string) (name, value string, ok bool) {
+	inQuotes := false
+	nameEnd := -1
+	for i, ch := range line {
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == ';' && !inQuotes && nameEnd == -1:
+			nameEnd = i
+		case ch == ':' && !inQuotes:
+			if nameEnd == -1 {
+				nameEnd = i
+			}
+			name = strings.ToUpper(strings.TrimSpace(line[:nameEnd]))
+			return name, line[i+1:], name != ""
+		}
+	}
+	return "", "", false
+}
+
+var kUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescape(s string) string {
+	return kUnescaper.Replace(s)
+}
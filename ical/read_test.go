@@ -0,0 +1,157 @@
+package ical_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/ical"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+const (
+	kCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20000325
+RRULE:FREQ=YEARLY
+SUMMARY:John's birthday (1967)
+BEGIN:VALARM
+SUMMARY:Ignored
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+DTSTART;TZID="America/New_York":20150517T090000
+RRULE:FREQ=YEARLY;BYMONTH=5
+SUMMARY:Birthday: Merna
+  Heitcamp
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20100321
+RRULE:FREQ=YEARLY
+SUMMARY:Party
+X-BIRTHDAY-NAME:Long\, Katie
+X-BIRTHDAY-YEAR:2010
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20100401
+SUMMARY:Dentist
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20100402
+RRULE:FREQ=YEARLY;INTERVAL=2
+SUMMARY:Bob's birthday
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20000229
+RRULE:FREQ=YEARLY
+SUMMARY:Leap's birthday (2011)
+END:VEVENT
+BEGIN:VEVENT
+DTSTART:20000325
+RRULE:FREQ=YEARLY
+SUMMARY:John birthday (1967)
+END:VEVENT
+END:VCALENDAR
+`
+)
+
+func TestRead(t *testing.T) {
+	assert := asserts.New(t)
+	var entries []birthday.Entry
+	var skipped []string
+	err := ical.Read(
+		strings.NewReader(kCalendar),
+		consume2.AppendTo(&entries),
+		func(s ical.Skipped) { skipped = append(skipped, s.String()) })
+	assert.NoError(err)
+	assert.Equal(
+		[]birthday.Entry{
+			{
+				Name:     "John",
+				Birthday: date_util.YMD(1967, 3, 25),
+				Id:       "256b5fe5d88d",
+			},
+			{
+				Name:     "Merna Heitcamp",
+				Birthday: date_util.YMD(0, 5, 17),
+				Id:       "ba87f8a8ba5b",
+			},
+			{
+				Name:     "Long, Katie",
+				Birthday: date_util.YMD(2010, 3, 21),
+				Id:       "fdf980569598",
+			},
+		},
+		entries)
+	assert.Equal(
+		[]string{
+			`Line 24: "Dentist": not a yearly event`,
+			`Line 28: "Bob's birthday": not a yearly event`,
+			`Line 33: "Leap's birthday (2011)": invalid birthday`,
+			`Line 38: "John birthday (1967)": duplicate entry`,
+		},
+		skipped)
+}
+
+func TestReadQuitEarly(t *testing.T) {
+	assert := asserts.New(t)
+	var entries []birthday.Entry
+	err := ical.Read(
+		strings.NewReader(kCalendar),
+		consume2.Slice(consume2.AppendTo(&entries), 0, 1),
+		nil)
+	assert.NoError(err)
+	assert.Len(entries, 1)
+}
+
+func TestReadMalformatted(t *testing.T) {
+	assert := asserts.New(t)
+	var entries []birthday.Entry
+	err := ical.Read(
+		strings.NewReader("BEGIN:VCALENDAR\nnonsense\n"),
+		consume2.AppendTo(&entries),
+		nil)
+	assert.EqualError(err, "Line 2 malformatted")
+}
+
+func TestFileStoreReportsSkippedOncePerChange(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "birthdays.ics")
+	if err := os.WriteFile(path, []byte(kCalendar), 0644); err != nil {
+		t.Fatal(err)
+	}
+	skipped := 0
+	store := &ical.FileStore{
+		Path: path, Skipped: func(ical.Skipped) { skipped++ }}
+	read := func() {
+		var entries []birthday.Entry
+		assert.NoError(store.Read(consume2.AppendTo(&entries)))
+		assert.Len(entries, 3)
+	}
+	read()
+	assert.Equal(4, skipped)
+	read()
+	assert.Equal(4, skipped)
+
+	// The file changes
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	read()
+	assert.Equal(8, skipped)
+}
+
+func TestNewStore(t *testing.T) {
+	assert := asserts.New(t)
+	_, ok := ical.NewStore("birthdays.ICS").(*ical.FileStore)
+	assert.True(ok)
+	assert.Equal(
+		birthday.SystemStore("birthdays.tsv"), ical.NewStore("birthdays.tsv"))
+}
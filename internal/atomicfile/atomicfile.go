@@ -0,0 +1,52 @@
+// Package atomicfile replaces files so that a crash never leaves a
+// partial file behind.
+package atomicfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces the contents of the file at path with contents by
+// writing and syncing a temporary file in the same directory and renaming
+// it. If the file at path exists, the new file keeps its permissions.
+// Otherwise the new file gets perm. If check is non-nil, Write calls it
+// just before the rename and leaves the file at path alone if check
+// returns an error.
+func Write(
+	path string, contents []byte, perm fs.FileMode, check func() error) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	tempName := temp.Name()
+	defer os.Remove(tempName)
+	if _, err := temp.Write(contents); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tempName, perm); err != nil {
+		return err
+	}
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tempName, path)
+}
@@ -0,0 +1,40 @@
+package atomicfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keep94/birthday/internal/atomicfile"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "state.json")
+	assert.NoError(atomicfile.Write(path, []byte("one"), 0600, nil))
+	info, err := os.Stat(path)
+	if assert.NoError(err) {
+		assert.Equal(os.FileMode(0600), info.Mode().Perm())
+	}
+	assert.NoError(os.Chmod(path, 0640))
+
+	// Keeps the permissions of the existing file
+	assert.NoError(atomicfile.Write(path, []byte("two"), 0600, nil))
+	info, err = os.Stat(path)
+	if assert.NoError(err) {
+		assert.Equal(os.FileMode(0640), info.Mode().Perm())
+	}
+
+	// A failed check leaves the file alone
+	checkErr := errors.New("changed")
+	assert.Equal(
+		checkErr,
+		atomicfile.Write(
+			path, []byte("three"), 0600, func() error { return checkErr }))
+	data, _ := os.ReadFile(path)
+	assert.Equal("two", string(data))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Len(entries, 1)
+}
@@ -0,0 +1,71 @@
+// Package contentline writes the content lines shared by iCalendar
+// (RFC 5545) and vCard (RFC 6350).
+package contentline
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	kMaxLineBytes = 75
+)
+
+var kEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// Escape escapes s for use as a TEXT value.
+func Escape(s string) string {
+	return kEscaper.Replace(s)
+}
+
+// Writer writes content lines folding them at 75 octets and ending each
+// with CRLF.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Line writes a content line. name may include parameters; value must
+// already be escaped.
+func (c *Writer) Line(name, value string) {
+	if c.err != nil {
+		return
+	}
+	line := name + ":" + value
+	for len(line) > kMaxLineBytes {
+		cut := kMaxLineBytes
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		c.write(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	c.write(line + "\r\n")
+}
+
+func (c *Writer) write(s string) {
+	if c.err == nil {
+		_, c.err = c.w.WriteString(s)
+	}
+}
+
+// Flush flushes buffered lines and returns the first error encountered.
+func (c *Writer) Flush() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.w.Flush()
+}
@@ -0,0 +1,26 @@
+// Package milestonetext writes milestones as lines of plain text.
+package milestonetext
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/keep94/birthday"
+)
+
+// Write writes milestone to w as one line of plain text. Milestones
+// falling on today are marked with an asterisk.
+func Write(w io.Writer, milestone *birthday.Milestone, today time.Time) {
+	astricks := " "
+	if milestone.Date.Equal(today) {
+		astricks = "*"
+	}
+	fmt.Fprintf(
+		w,
+		"%s %14s %20s %s\n",
+		astricks,
+		birthday.ToStringWithWeekDay(milestone.Date),
+		milestone.AgeString(),
+		milestone.EntryPtr.Name)
+}
@@ -0,0 +1,248 @@
+// Package mute hides people and milestones from reminders either for
+// good or until a date.
+package mute
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/internal/atomicfile"
+	"github.com/keep94/itertools"
+)
+
+// Rule hides either all the milestones of a person or one milestone.
+type Rule struct {
+
+	// The id of the person this rule mutes. Empty if this rule mutes a
+	// single milestone.
+	EntryId string
+
+	// The key of the milestone this rule hides. See
+	// birthday.Milestone.Key. Empty if this rule mutes a person.
+	Key string
+
+	// The rule hides only milestones before this date. The zero value
+	// means the rule hides milestones for good.
+	Until time.Time
+}
+
+// Forever returns true if this rule hides milestones for good.
+func (r *Rule) Forever() bool {
+	return r.Until.IsZero()
+}
+
+// Hides returns true if this rule hides milestone.
+func (r *Rule) Hides(milestone *birthday.Milestone) bool {
+	if r.EntryId != "" {
+		if milestone.EntryPtr.Id != r.EntryId {
+			return false
+		}
+	} else if milestone.Key() != r.Key {
+		return false
+	}
+	return r.Forever() || milestone.Date.Before(r.Until)
+}
+
+func (r *Rule) target() string {
+	if r.EntryId != "" {
+		return r.EntryId
+	}
+	return r.Key
+}
+
+// Rules is a set of rules stored in a JSON file. A nil *Rules has no
+// rules and hides nothing. Rules is safe to use from multiple goroutines.
+type Rules struct {
+	path   string
+	mu     sync.Mutex
+	people map[string]Rule
+	keys   map[string]Rule
+}
+
+type ruleJSON struct {
+	EntryId string `json:"entryId,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Until   string `json:"until,omitempty"`
+}
+
+// Open opens the rules at path. If there is no file at path, Open returns
+// no rules and creates the file on the first change.
+func Open(path string) (*Rules, error) {
+	result := &Rules{
+		path:   path,
+		people: make(map[string]Rule),
+		keys:   make(map[string]Rule),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []ruleJSON
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		rule := Rule{EntryId: r.EntryId, Key: r.Key}
+		if r.Until != "" {
+			rule.Until, err = time.Parse(time.DateOnly, r.Until)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.set(rule)
+	}
+	return result, nil
+}
+
+// Hides returns true if any rule hides milestone.
+func (r *Rules) Hides(milestone *birthday.Milestone) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rule, ok := r.people[milestone.EntryPtr.Id]
+	if ok && rule.Hides(milestone) {
+		return true
+	}
+	rule, ok = r.keys[milestone.Key()]
+	return ok && rule.Hides(milestone)
+}
+
+// Filter returns seq without the milestones that these rules hide.
+func (r *Rules) Filter(
+	seq iter.Seq[*birthday.Milestone]) iter.Seq[*birthday.Milestone] {
+	if r == nil {
+		return seq
+	}
+	return itertools.Filter(
+		func(m *birthday.Milestone) bool { return !r.Hides(m) }, seq)
+}
+
+// Person returns the rule muting the person with id. Person returns false
+// if there is no such rule.
+func (r *Rules) Person(id string) (Rule, bool) {
+	if r == nil {
+		return Rule{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.people[id]
+	return result, ok
+}
+
+// Milestone returns the rule hiding the milestone with key. Milestone
+// returns false if there is no such rule.
+func (r *Rules) Milestone(key string) (Rule, bool) {
+	if r == nil {
+		return Rule{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.keys[key]
+	return result, ok
+}
+
+// List returns all the rules, people first.
+func (r *Rules) List() []Rule {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.list()
+}
+
+// Add adds rule replacing any rule for the same person or milestone.
+// rule must have exactly one of EntryId and Key.
+func (r *Rules) Add(rule Rule) error {
+	if (rule.EntryId == "") == (rule.Key == "") {
+		return errors.New("rule needs exactly one of EntryId and Key")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.change(func() { r.set(rule) })
+}
+
+// Remove removes the rule for the same person or milestone as rule if
+// there is one.
+func (r *Rules) Remove(rule Rule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.change(func() {
+		if rule.EntryId != "" {
+			delete(r.people, rule.EntryId)
+		} else {
+			delete(r.keys, rule.Key)
+		}
+	})
+}
+
+// change applies f and saves. If saving fails, change undoes f.
+func (r *Rules) change(f func()) error {
+	oldPeople := make(map[string]Rule, len(r.people))
+	oldKeys := make(map[string]Rule, len(r.keys))
+	for k, v := range r.people {
+		oldPeople[k] = v
+	}
+	for k, v := range r.keys {
+		oldKeys[k] = v
+	}
+	f()
+	if err := r.save(); err != nil {
+		r.people, r.keys = oldPeople, oldKeys
+		return err
+	}
+	return nil
+}
+
+func (r *Rules) set(rule Rule) {
+	if rule.EntryId != "" {
+		r.people[rule.EntryId] = rule
+	} else {
+		r.keys[rule.Key] = rule
+	}
+}
+
+func (r *Rules) list() []Rule {
+	var people, keys []Rule
+	for _, rule := range r.people {
+		people = append(people, rule)
+	}
+	for _, rule := range r.keys {
+		keys = append(keys, rule)
+	}
+	byTarget := func(a, b Rule) int {
+		return strings.Compare(a.target(), b.target())
+	}
+	slices.SortFunc(people, byTarget)
+	slices.SortFunc(keys, byTarget)
+	return append(people, keys...)
+}
+
+func (r *Rules) save() error {
+	rules := []ruleJSON{}
+	for _, rule := range r.list() {
+		j := ruleJSON{EntryId: rule.EntryId, Key: rule.Key}
+		if !rule.Forever() {
+			j.Until = rule.Until.Format(time.DateOnly)
+		}
+		rules = append(rules, j)
+	}
+	data, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(r.path, data, 0644, nil)
+}
@@ -0,0 +1,98 @@
+package mute_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+var (
+	kEntries = []*birthday.Entry{
+		{Name: "Mark Smith", Birthday: date_util.YMD(1990, 3, 1), Id: "mark"},
+		{Name: "Ann Jones", Birthday: date_util.YMD(1985, 3, 4), Id: "ann"},
+		{Name: "Bob Jones", Birthday: date_util.YMD(0, 3, 9), Id: "bob"},
+	}
+)
+
+func open(t *testing.T, path string) *mute.Rules {
+	result, err := mute.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// names returns who has a birthday each year from 2024 through 2026
+// that rules doesn't hide.
+func names(rules *mute.Rules) []string {
+	var result []string
+	seq := itertools.TakeWhile(
+		func(m *birthday.Milestone) bool {
+			return m.Date.Before(date_util.YMD(2027, 1, 1))
+		},
+		birthday.RemindPtrs(
+			kEntries,
+			[]birthday.Period{{Years: 1}},
+			date_util.YMD(2024, 1, 1)))
+	for m := range rules.Filter(seq) {
+		result = append(
+			result, m.EntryPtr.Id+" "+m.Date.Format("2006"))
+	}
+	return result
+}
+
+func TestRules(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "mute.json")
+	rules := open(t, path)
+	assert.Len(names(rules), 9)
+
+	// Snooze Bob until 2025 and hide Mark's 35th birthday
+	assert.NoError(rules.Add(
+		mute.Rule{EntryId: "bob", Until: date_util.YMD(2025, 3, 10)}))
+	assert.NoError(rules.Add(mute.Rule{Key: "mark-1y0m0w0d-35"}))
+	assert.Error(rules.Add(mute.Rule{}))
+	assert.Error(rules.Add(mute.Rule{EntryId: "bob", Key: "bob-1y0m0w0d-2"}))
+	expected := []string{
+		"mark 2024", "ann 2024",
+		"ann 2025",
+		"mark 2026", "ann 2026", "bob 2026",
+	}
+	assert.Equal(expected, names(rules))
+
+	// Survives reopening
+	rules = open(t, path)
+	assert.Equal(expected, names(rules))
+	assert.Equal(
+		[]mute.Rule{
+			{EntryId: "bob", Until: date_util.YMD(2025, 3, 10)},
+			{Key: "mark-1y0m0w0d-35"},
+		},
+		rules.List())
+	rule, ok := rules.Person("bob")
+	assert.True(ok)
+	assert.False(rule.Forever())
+	_, ok = rules.Milestone("mark-1y0m0w0d-35")
+	assert.True(ok)
+
+	// Mute Ann for good and unhide Mark's 35th
+	assert.NoError(rules.Add(mute.Rule{EntryId: "ann"}))
+	assert.NoError(rules.Remove(mute.Rule{Key: "mark-1y0m0w0d-35"}))
+	assert.Equal(
+		[]string{"mark 2024", "mark 2025", "mark 2026", "bob 2026"},
+		names(rules))
+}
+
+func TestNilRules(t *testing.T) {
+	assert := asserts.New(t)
+	var rules *mute.Rules
+	assert.Len(names(rules), 9)
+	assert.Empty(rules.List())
+	_, ok := rules.Person("bob")
+	assert.False(ok)
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/keep94/toolbox/date_util"
+)
+
+// Email sends digests as HTML and plain text email through an SMTP
+// server.
+type Email struct {
+
+	// The host:port of the SMTP server
+	Addr string
+
+	// Optional authentication
+	Auth smtp.Auth
+
+	From string
+	To   []string
+
+	// The default is "Upcoming special days". A subject template in
+	// Templates takes precedence.
+	Subject string
+
+	// nil means the built-in templates.
+	Templates *Templates
+
+	// Optional. Overrides the name of this sink. See Sink.Name.
+	Channel string
+
+	// Optional. The clock for the Date header. The default is the system
+	// clock.
+	Clock date_util.Clock
+}
+
+// Name returns Channel or "email" if Channel is empty.
+func (e *Email) Name() string {
+	if e.Channel != "" {
+		return e.Channel
+	}
+	return "email"
+}
+
+// Send emails digest to all the recipients.
+func (e *Email) Send(digest *Digest) error {
+	msg, err := e.Message(digest)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(e.Addr, e.Auth, e.From, e.To, msg)
+}
+
+// Render returns the subject and the bodies of the email for digest.
+func (e *Email) Render(digest *Digest) (*Message, error) {
+	result, err := e.Templates.Render(digest)
+	if err != nil {
+		return nil, err
+	}
+	if e.Subject != "" && (e.Templates == nil || e.Templates.Subject == nil) {
+		result.Subject = e.Subject
+	}
+	return result, nil
+}
+
+// Message returns the email message for digest, headers and all, as
+// multipart/alternative with a plain text part and an HTML part.
+func (e *Email) Message(digest *Digest) ([]byte, error) {
+	rendered, err := e.Render(digest)
+	if err != nil {
+		return nil, err
+	}
+	boundary, err := newBoundary()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", e.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", rendered.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(
+		&buf,
+		"Content-Type: multipart/alternative; boundary=%q\r\n\r\n",
+		boundary)
+	writePart(&buf, boundary, "text/plain", []byte(rendered.Text))
+	writePart(&buf, boundary, "text/html", []byte(rendered.HTML))
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+func (e *Email) now() time.Time {
+	if e.Clock == nil {
+		return time.Now()
+	}
+	return e.Clock.Now()
+}
+
+func writePart(w io.Writer, boundary, contentType string, body []byte) {
+	fmt.Fprintf(w, "--%s\r\n", boundary)
+	fmt.Fprintf(w, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	fmt.Fprint(w, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(w)
+	qp.Write(body)
+	qp.Close()
+	fmt.Fprint(w, "\r\n")
+}
+
+func newBoundary() (string, error) {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,147 @@
+package notify_test
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/notify"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts one message.
+type smtpStandIn struct {
+	listener net.Listener
+	from     string
+	to       []string
+	data     chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpStandIn{listener: listener, data: make(chan string, 1)}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpStandIn) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		io.WriteString(conn, line+"\r\n")
+	}
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 Go ahead")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			s.data <- data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	assert := asserts.New(t)
+	server := newSMTPStandIn(t)
+	email := &notify.Email{
+		Addr:    server.Addr(),
+		From:    "remind@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "Birthdays this week",
+		Clock:   fixedClock(time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)),
+	}
+	assert.Equal("email", email.Name())
+	assert.NoError(newNotifier(email).Notify())
+	assert.Equal("remind@example.com", server.from)
+	assert.Equal([]string{"a@example.com", "b@example.com"}, server.to)
+	msg, err := mail.ReadMessage(strings.NewReader(<-server.data))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("Birthdays this week", msg.Header.Get("Subject"))
+	assert.Equal("a@example.com, b@example.com", msg.Header.Get("To"))
+	assert.Equal("Fri, 01 Mar 2024 15:00:00 +0000", msg.Header.Get("Date"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(err)
+	assert.Equal("multipart/alternative", mediaType)
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := parts.NextPart()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("text/plain; charset=utf-8", text.Header.Get("Content-Type"))
+	body, _ := io.ReadAll(text)
+	assert.Contains(string(body), "Fri 03/01/2024 (today): Mark Smith 34 years")
+	assert.Contains(string(body), "Mon 03/04/2024 (in 3 days): Ann Jones 39 years")
+	html, err := parts.NextPart()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("text/html; charset=utf-8", html.Header.Get("Content-Type"))
+	body, _ = io.ReadAll(html)
+	assert.Contains(string(body), "<td>Ann Jones</td>")
+	_, err = parts.NextPart()
+	assert.Equal(io.EOF, err)
+}
+
+func TestEmailUnreachable(t *testing.T) {
+	assert := asserts.New(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	email := &notify.Email{
+		Addr: addr, From: "remind@example.com", To: []string{"a@example.com"}}
+	assert.Error(newNotifier(email).Notify())
+}
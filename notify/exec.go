@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kDefaultExecTimeout = 30 * time.Second
+
+	// The most stderr output an error message includes
+	kMaxStderr = 1024
+)
+
+// Exec runs a command for each digest or for each milestone.
+//
+// When running once per digest, the command gets the same JSON on
+// standard input that a Generic Webhook posts. The environment has
+// BIRTHDAY_DIGEST_DATE, the date of the digest as YYYY-MM-DD, and
+// BIRTHDAY_COUNT, the number of milestones.
+//
+// When running once per milestone, the command gets the milestone as a
+// JSON object on standard input, the same object as in the milestones
+// list of the Generic payload. The environment has BIRTHDAY_DIGEST_DATE
+// along with BIRTHDAY_DATE, BIRTHDAY_DAYS_UNTIL, BIRTHDAY_WHEN,
+// BIRTHDAY_ID, BIRTHDAY_NAME and BIRTHDAY_AGE.
+//
+// In both cases the command inherits the rest of its environment from
+// the current process.
+type Exec struct {
+
+	// The command and its arguments. The command runs directly, not
+	// through a shell.
+	Command []string
+
+	// If true, run the command once per milestone instead of once per
+	// digest.
+	PerMilestone bool
+
+	// How long each run of the command may take. The default is
+	// 30 seconds.
+	Timeout time.Duration
+
+	// Optional. Overrides the name of this sink. See Sink.Name.
+	Channel string
+}
+
+// Name returns Channel or, if Channel is empty, "exec" followed by the
+// base name of the command.
+func (e *Exec) Name() string {
+	if e.Channel != "" {
+		return e.Channel
+	}
+	if len(e.Command) == 0 {
+		return "exec"
+	}
+	return "exec " + filepath.Base(e.Command[0])
+}
+
+// Send runs the command for digest. Send returns an error for each run
+// that failed, timed out, or exited with a non-zero status. When running
+// per milestone, one failed run does not keep the others from running,
+// and Send reports the failed runs with a *PartialError.
+func (e *Exec) Send(digest *Digest) error {
+	if len(e.Command) == 0 {
+		return errors.New("no command")
+	}
+	digestDate := "BIRTHDAY_DIGEST_DATE=" + digest.Date.Format(time.DateOnly)
+	if !e.PerMilestone {
+		stdin, err := json.Marshal(newGenericPayload(digest))
+		if err != nil {
+			return err
+		}
+		return e.run(
+			stdin,
+			digestDate,
+			"BIRTHDAY_COUNT="+strconv.Itoa(len(digest.Milestones)))
+	}
+	errs := make(map[string]error)
+	for _, m := range digest.Milestones {
+		milestone := newGenericMilestone(digest, m)
+		stdin, err := json.Marshal(milestone)
+		if err != nil {
+			return err
+		}
+		err = e.run(
+			stdin,
+			digestDate,
+			"BIRTHDAY_DATE="+milestone.Date,
+			"BIRTHDAY_DAYS_UNTIL="+strconv.Itoa(milestone.DaysUntil),
+			"BIRTHDAY_WHEN="+milestone.When,
+			"BIRTHDAY_ID="+milestone.Id,
+			"BIRTHDAY_NAME="+milestone.Name,
+			"BIRTHDAY_AGE="+milestone.Age)
+		if err != nil {
+			errs[m.Key()] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &PartialError{Errors: errs}
+	}
+	return nil
+}
+
+func (e *Exec) run(stdin []byte, env ...string) error {
+	timeout := e.Timeout
+	if timeout == 0 {
+		timeout = kDefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Env = append(os.Environ(), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	// Don't wait forever on children of the command that keep stderr open.
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if len(message) > kMaxStderr {
+			message = message[len(message)-kMaxStderr:]
+		}
+		if message != "" {
+			return fmt.Errorf("%w: %s", err, message)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/notify"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+// shell returns a command that runs script with sh. The script can
+// refer to out, a file in a fresh temporary directory, as $OUT.
+func shell(t *testing.T, script string) (command []string, out string) {
+	out = filepath.Join(t.TempDir(), "out")
+	t.Setenv("OUT", out)
+	return []string{"/bin/sh", "-c", script}, out
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExecPerDigest(t *testing.T) {
+	assert := asserts.New(t)
+	command, out := shell(
+		t,
+		`cat > "$OUT"; echo >> "$OUT"; `+
+			`echo "$BIRTHDAY_DIGEST_DATE $BIRTHDAY_COUNT" >> "$OUT"`)
+	hook := &notify.Exec{Command: command}
+	assert.Equal("exec sh", hook.Name())
+	assert.NoError(newNotifier(hook).Notify())
+	stdin, env, _ := strings.Cut(readFile(t, out), "\n")
+	assert.Equal("2024-03-01 2\n", env)
+	var payload struct {
+		Date       string
+		Milestones []struct {
+			Name string
+		}
+	}
+	assert.NoError(json.Unmarshal([]byte(stdin), &payload))
+	assert.Equal("2024-03-01", payload.Date)
+	assert.Len(payload.Milestones, 2)
+}
+
+func TestExecPerMilestone(t *testing.T) {
+	assert := asserts.New(t)
+	command, out := shell(
+		t,
+		`echo "$BIRTHDAY_DATE|$BIRTHDAY_DAYS_UNTIL|$BIRTHDAY_WHEN|`+
+			`$BIRTHDAY_ID|$BIRTHDAY_NAME|$BIRTHDAY_AGE|`+
+			`$(cat)" >> "$OUT"`)
+	hook := &notify.Exec{Command: command, PerMilestone: true}
+	assert.NoError(newNotifier(hook).Notify())
+	lines := strings.Split(strings.TrimSpace(readFile(t, out)), "\n")
+	if !assert.Len(lines, 2) {
+		return
+	}
+	assert.True(
+		strings.HasPrefix(
+			lines[1],
+			"2024-03-04|3|in 3 days|ann|Ann Jones|39 years|{"),
+		lines[1])
+	var milestone struct {
+		Name      string
+		DaysUntil int
+	}
+	_, stdin, _ := strings.Cut(lines[1], "|{")
+	assert.NoError(json.Unmarshal([]byte("{"+stdin), &milestone))
+	assert.Equal("Ann Jones", milestone.Name)
+	assert.Equal(3, milestone.DaysUntil)
+}
+
+func TestExecFailure(t *testing.T) {
+	assert := asserts.New(t)
+	command, _ := shell(t, `echo "no gateway" >&2; exit 3`)
+	hook := &notify.Exec{Command: command, PerMilestone: true}
+	err := newNotifier(hook).Notify()
+	assert.ErrorContains(err, "exec sh: ")
+	assert.ErrorContains(err, "exit status 3: no gateway")
+
+	// One error per milestone
+	assert.Equal(2, strings.Count(err.Error(), "no gateway"))
+}
+
+func TestExecTimeout(t *testing.T) {
+	assert := asserts.New(t)
+	command, _ := shell(t, `sleep 5`)
+	hook := &notify.Exec{Command: command, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	err := newNotifier(hook).Notify()
+	assert.ErrorContains(err, "timed out after 50ms")
+	assert.Less(time.Since(start), 3*time.Second)
+}
+
+func TestExecNotFound(t *testing.T) {
+	assert := asserts.New(t)
+	hook := &notify.Exec{Command: []string{"/no/such/command"}}
+	assert.Error(newNotifier(hook).Notify())
+}
+
+func TestExecPerMilestonePartialFailure(t *testing.T) {
+	assert := asserts.New(t)
+	command, out := shell(
+		t,
+		`if [ "$BIRTHDAY_ID" = ann ]; then echo "busy" >&2; exit 1; fi; `+
+			`echo "$BIRTHDAY_ID" >> "$OUT"`)
+	hook := &notify.Exec{Command: command, PerMilestone: true}
+	notifier := newNotifier(hook)
+	notifier.Log = openLog(t, filepath.Join(t.TempDir(), "sent.log"))
+	err := notifier.Notify()
+	var partial *notify.PartialError
+	if assert.ErrorAs(err, &partial) {
+		assert.Len(partial.Errors, 1)
+		assert.Contains(partial.Errors, "ann-1y0m0w0d-39")
+	}
+	entries := notifier.Log.Entries()
+	if assert.Len(entries, 2) {
+		for _, entry := range entries {
+			assert.Equal(entry.Name == "Ann Jones", !entry.Succeeded())
+		}
+	}
+
+	// Only the failed milestone runs again
+	notifier.Notify()
+	assert.Equal("mark\n", readFile(t, out))
+}
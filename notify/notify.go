@@ -0,0 +1,213 @@
+// Package notify sends digests of upcoming milestones to people through
+// sinks such as email.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/consume2"
+	"github.com/keep94/itertools"
+	"github.com/keep94/toolbox/date_util"
+)
+
+// Digest is the milestones coming up as of a particular date.
+type Digest struct {
+
+	// The date of the digest
+	Date time.Time
+
+	// The upcoming milestones in chronological order
+	Milestones []*birthday.Milestone
+}
+
+// DaysUntil returns the number of days from the date of this digest
+// until milestone.
+func (d *Digest) DaysUntil(milestone *birthday.Milestone) int {
+	return birthday.DaysUntil(d.Date, milestone.Date)
+}
+
+// When returns when milestone happens relative to the date of this
+// digest e.g "today", "tomorrow" or "in 7 days".
+func (d *Digest) When(milestone *birthday.Milestone) string {
+	return birthday.When(d.DaysUntil(milestone))
+}
+
+// Sink delivers digests.
+type Sink interface {
+
+	// Name returns the name of this sink for logging e.g "email". The
+	// sent log tells sinks apart by name, so the sinks of a Notifier
+	// must have different names, and a sink's name must not change
+	// between runs.
+	Name() string
+
+	// Send delivers digest.
+	Send(digest *Digest) error
+}
+
+// PartialError reports the milestones of a digest that a sink failed to
+// deliver. The sink delivered the rest of the milestones.
+type PartialError struct {
+
+	// The errors by milestone key. See birthday.Milestone.Key.
+	Errors map[string]error
+}
+
+func (e *PartialError) Error() string {
+	keys := slices.Sorted(maps.Keys(e.Errors))
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+": "+e.Errors[key].Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e *PartialError) Unwrap() []error {
+	return slices.Collect(maps.Values(e.Errors))
+}
+
+// Notifier sends a digest of upcoming milestones to each of its sinks.
+type Notifier struct {
+	Store birthday.Store
+
+	// Only entries matching Query are included. Empty means all entries.
+	Query string
+
+	Periods []birthday.Period
+
+	// The lead time. A digest includes the milestones from today through
+	// DaysAhead days from today that no policy covers.
+	DaysAhead int
+
+	// Reminder policies. Milestones use the first policy that applies.
+	// A digest includes a milestone that a policy covers only on the
+	// days the policy calls for a reminder.
+	Policies []Policy
+
+	Sinks []Sink
+
+	// If non-nil, Notify records each delivery attempt here and doesn't
+	// deliver a reminder over a sink that has already delivered it.
+	Log *SentLog
+
+	// Optional. Digests leave out the milestones these rules hide.
+	Mute *mute.Rules
+
+	Clock date_util.Clock
+}
+
+// Digest returns the digest for today.
+func (n *Notifier) Digest() (*Digest, error) {
+	return n.DigestOn(birthday.Today(n.Clock))
+}
+
+// DigestOn returns the digest for today as if today were the given date.
+func (n *Notifier) DigestOn(today time.Time) (*Digest, error) {
+	var entries []*birthday.Entry
+	err := n.Store.Read(
+		consume2.Filter(
+			consume2.AppendPtrsTo(&entries), birthday.Query(n.Query)))
+	if err != nil {
+		return nil, err
+	}
+	end := today.AddDate(
+		0, 0, max(n.DaysAhead, maxLeadDays(n.Policies))+1)
+	result := &Digest{Date: today}
+	milestones := itertools.TakeWhile(
+		func(m *birthday.Milestone) bool { return m.Date.Before(end) },
+		n.Mute.Filter(birthday.RemindPtrs(entries, n.Periods, today)))
+	for m := range milestones {
+		daysUntil := result.DaysUntil(m)
+		if p := n.policy(m); p != nil {
+			if p.Due(daysUntil) {
+				result.Milestones = append(result.Milestones, m)
+			}
+		} else if daysUntil <= n.DaysAhead {
+			result.Milestones = append(result.Milestones, m)
+		}
+	}
+	return result, nil
+}
+
+// Notify sends today's digest to each sink. If there is a Log, each sink
+// gets only the milestones it hasn't already delivered. Notify sends
+// nothing to a sink if there is nothing for it. A failing sink does not
+// keep the other sinks from getting the digest. Notify returns the errors
+// of all the sinks that failed.
+func (n *Notifier) Notify() error {
+	digest, err := n.Digest()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sink := range n.Sinks {
+		unsent := n.unsent(sink.Name(), digest)
+		if len(unsent.Milestones) == 0 {
+			continue
+		}
+		sendErr := sink.Send(unsent)
+		if sendErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), sendErr))
+		}
+		if err := n.record(sink.Name(), unsent, sendErr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// unsent returns digest with only the milestones not yet delivered over
+// channel.
+func (n *Notifier) unsent(channel string, digest *Digest) *Digest {
+	if n.Log == nil {
+		return digest
+	}
+	result := &Digest{Date: digest.Date}
+	for _, m := range digest.Milestones {
+		if !n.Log.Sent(channel, m.Key(), n.lead(digest, m)) {
+			result.Milestones = append(result.Milestones, m)
+		}
+	}
+	return result
+}
+
+// record records the attempt to deliver digest over channel. If sendErr
+// is a PartialError, record records only the milestones it lists as
+// failed.
+func (n *Notifier) record(channel string, digest *Digest, sendErr error) error {
+	if n.Log == nil {
+		return nil
+	}
+	now := n.Clock.Now()
+	var partial *PartialError
+	errors.As(sendErr, &partial)
+	var entries []*LogEntry
+	for _, m := range digest.Milestones {
+		var errStr string
+		if partial != nil {
+			if err := partial.Errors[m.Key()]; err != nil {
+				errStr = err.Error()
+			}
+		} else if sendErr != nil {
+			errStr = sendErr.Error()
+		}
+		entries = append(entries, &LogEntry{
+			Time:    now,
+			Channel: channel,
+			Key:     m.Key(),
+			Lead:    n.lead(digest, m),
+			Name:    m.EntryPtr.Name,
+			Date:    m.Date.Format(time.DateOnly),
+			Age:     m.AgeString(),
+			Error:   errStr,
+		})
+	}
+	return n.Log.Record(entries...)
+}
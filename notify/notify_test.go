@@ -0,0 +1,178 @@
+package notify_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/mute"
+	"github.com/keep94/birthday/notify"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+var (
+	kEntries = []birthday.Entry{
+		{Name: "Mark Smith", Birthday: date_util.YMD(1990, 3, 1), Id: "mark"},
+		{Name: "Ann Jones", Birthday: date_util.YMD(1985, 3, 4), Id: "ann"},
+		{Name: "Bob Jones", Birthday: date_util.YMD(0, 3, 9), Id: "bob"},
+	}
+)
+
+type entriesStore []birthday.Entry
+
+func (s entriesStore) Read(consumer consume2.Consumer[birthday.Entry]) error {
+	for _, entry := range s {
+		if !consumer.CanConsume() {
+			break
+		}
+		consumer.Consume(entry)
+	}
+	return nil
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type fakeSink struct {
+	name    string
+	err     error
+	digests []*notify.Digest
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Send(digest *notify.Digest) error {
+	s.digests = append(s.digests, digest)
+	return s.err
+}
+
+func newNotifier(sinks ...notify.Sink) *notify.Notifier {
+	return &notify.Notifier{
+		Store:     entriesStore(kEntries),
+		Periods:   []birthday.Period{{Years: 1}},
+		DaysAhead: 3,
+		Sinks:     sinks,
+		Clock:     fixedClock(time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestDigest(t *testing.T) {
+	assert := asserts.New(t)
+	digest, err := newNotifier().Digest()
+	assert.NoError(err)
+	assert.Equal(date_util.YMD(2024, 3, 1), digest.Date)
+	if assert.Len(digest.Milestones, 2) {
+		assert.Equal("Mark Smith", digest.Milestones[0].EntryPtr.Name)
+		assert.Equal("today", digest.When(digest.Milestones[0]))
+		assert.Equal("Ann Jones", digest.Milestones[1].EntryPtr.Name)
+		assert.Equal(3, digest.DaysUntil(digest.Milestones[1]))
+		assert.Equal("in 3 days", digest.When(digest.Milestones[1]))
+	}
+}
+
+func TestDigestQuery(t *testing.T) {
+	assert := asserts.New(t)
+	notifier := newNotifier()
+	notifier.Query = "jones"
+	notifier.DaysAhead = 10
+	digest, err := notifier.Digest()
+	assert.NoError(err)
+	if assert.Len(digest.Milestones, 2) {
+		assert.Equal("Ann Jones", digest.Milestones[0].EntryPtr.Name)
+		assert.Equal("Bob Jones", digest.Milestones[1].EntryPtr.Name)
+	}
+}
+
+func TestDigestMute(t *testing.T) {
+	assert := asserts.New(t)
+	rules, err := mute.Open(filepath.Join(t.TempDir(), "mute.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(rules.Add(mute.Rule{EntryId: "mark"}))
+	notifier := newNotifier()
+	notifier.Mute = rules
+	digest, err := notifier.Digest()
+	assert.NoError(err)
+	if assert.Len(digest.Milestones, 1) {
+		assert.Equal("Ann Jones", digest.Milestones[0].EntryPtr.Name)
+	}
+}
+
+func TestSinkChannel(t *testing.T) {
+	assert := asserts.New(t)
+	hook := &notify.Webhook{URL: "https://hooks.slack.com/services/a"}
+	assert.Equal("webhook hooks.slack.com", hook.Name())
+	hook.Channel = "family chat"
+	assert.Equal("family chat", hook.Name())
+	command := &notify.Exec{Command: []string{"/bin/sms"}, Channel: "sms"}
+	assert.Equal("sms", command.Name())
+	email := &notify.Email{Channel: "work email"}
+	assert.Equal("work email", email.Name())
+}
+
+func TestNotify(t *testing.T) {
+	assert := asserts.New(t)
+	first := &fakeSink{name: "first", err: errors.New("down")}
+	second := &fakeSink{name: "second"}
+	err := newNotifier(first, second).Notify()
+	assert.EqualError(err, "first: down")
+	assert.Len(first.digests, 1)
+	assert.Len(second.digests, 1)
+}
+
+func TestNotifyNothingUpcoming(t *testing.T) {
+	assert := asserts.New(t)
+	sink := &fakeSink{name: "sink"}
+	notifier := newNotifier(sink)
+	notifier.Query = "nobody"
+	assert.NoError(notifier.Notify())
+	assert.Empty(sink.digests)
+}
+
+func TestPolicies(t *testing.T) {
+	assert := asserts.New(t)
+	yearly := []birthday.Period{{Years: 1}}
+	sink := &fakeSink{name: "sink"}
+	notifier := newNotifier(sink)
+	notifier.Policies = []notify.Policy{
+		{Periods: yearly, Every: 3, LeadDays: []int{7, 1, 0}},
+		{Periods: yearly, LeadDays: []int{0}},
+	}
+	notifier.Log = openLog(t, filepath.Join(t.TempDir(), "sent.log"))
+	var reminders []string
+	for day := 20; day <= 40; day++ {
+		now := time.Date(2024, 2, day, 8, 0, 0, 0, time.UTC)
+		notifier.Clock = fixedClock(now)
+		for i := 0; i < 2; i++ {
+			sink.digests = nil
+			assert.NoError(notifier.Notify())
+			for _, digest := range sink.digests {
+				for _, m := range digest.Milestones {
+					reminders = append(
+						reminders,
+						digest.Date.Format("01-02")+" "+
+							m.EntryPtr.Name+" "+digest.When(m))
+				}
+			}
+		}
+	}
+	assert.Equal(
+		[]string{
+			"02-26 Ann Jones in 7 days",
+			"03-01 Mark Smith today",
+			"03-03 Ann Jones tomorrow",
+			"03-04 Ann Jones today",
+			"03-09 Bob Jones today",
+		},
+		reminders)
+}
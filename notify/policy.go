@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/keep94/birthday"
+)
+
+// Policy says how many days ahead to remind people of certain
+// milestones. For example, a week's notice to buy a gift for birthdays
+// ending in 0, one day's notice to call, and a reminder the day of.
+type Policy struct {
+
+	// The periods this policy applies to. Empty means all periods.
+	Periods []birthday.Period
+
+	// If non-zero, this policy applies only to milestones whose count of
+	// periods is a multiple of Every. For example, with yearly periods,
+	// an Every of 10 means birthdays ending in 0. A policy with non-zero
+	// Every never applies to milestones where the age is unknown.
+	Every int
+
+	// How many days before the milestone to send reminders. 0 means the
+	// day of the milestone.
+	LeadDays []int
+}
+
+// Applies returns true if this policy applies to milestone.
+func (p *Policy) Applies(milestone *birthday.Milestone) bool {
+	if len(p.Periods) > 0 && !slices.Contains(p.Periods, milestone.Period) {
+		return false
+	}
+	if p.Every != 0 {
+		return !milestone.AgeUnknown && milestone.Count%p.Every == 0
+	}
+	return true
+}
+
+// Due returns true if this policy calls for a reminder daysUntil days
+// before a milestone.
+func (p *Policy) Due(daysUntil int) bool {
+	return slices.Contains(p.LeadDays, daysUntil)
+}
+
+// maxLeadDays returns the largest lead time in policies or 0 if there
+// are none.
+func maxLeadDays(policies []Policy) int {
+	result := 0
+	for _, p := range policies {
+		for _, lead := range p.LeadDays {
+			result = max(result, lead)
+		}
+	}
+	return result
+}
+
+// policy returns the first policy of n that applies to milestone or nil
+// if none do.
+func (n *Notifier) policy(milestone *birthday.Milestone) *Policy {
+	for i := range n.Policies {
+		if n.Policies[i].Applies(milestone) {
+			return &n.Policies[i]
+		}
+	}
+	return nil
+}
+
+// lead returns the lead time that puts milestone in digest as the sent
+// log records it. lead returns "" for milestones that no policy covers
+// as those get only one reminder.
+func (n *Notifier) lead(digest *Digest, milestone *birthday.Milestone) string {
+	if n.policy(milestone) == nil {
+		return ""
+	}
+	return strconv.Itoa(digest.DaysUntil(milestone))
+}
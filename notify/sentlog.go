@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LogEntry records one attempt to deliver one milestone over one
+// channel.
+type LogEntry struct {
+
+	// When the attempt happened
+	Time time.Time `json:"time"`
+
+	// The name of the sink e.g "email"
+	Channel string `json:"channel"`
+
+	// The key of the milestone. See birthday.Milestone.Key.
+	Key string `json:"key"`
+
+	// The number of days ahead of the milestone this reminder is for
+	// according to its Policy. Empty if no policy covers the milestone.
+	Lead string `json:"lead,omitempty"`
+
+	// The name of the person
+	Name string `json:"name"`
+
+	// The date of the milestone as YYYY-MM-DD
+	Date string `json:"date"`
+
+	// The age at the milestone e.g "40 years"
+	Age string `json:"age"`
+
+	// Empty if delivery succeeded
+	Error string `json:"error,omitempty"`
+}
+
+// Succeeded returns true if delivery succeeded.
+func (e *LogEntry) Succeeded() bool {
+	return e.Error == ""
+}
+
+// SentLog is a persistent log of delivery attempts backed by a file with
+// one JSON object per line. Notifier consults a SentLog so that it
+// delivers each reminder over each channel only once. SentLog is safe
+// to use from multiple goroutines.
+type SentLog struct {
+	path    string
+	mu      sync.Mutex
+	entries []*LogEntry
+	sent    map[sentKey]bool
+}
+
+type sentKey struct {
+	channel string
+	key     string
+	lead    string
+}
+
+// OpenSentLog opens the sent log at path. If there is no file at path,
+// OpenSentLog returns an empty log and creates the file on the first
+// write.
+func OpenSentLog(path string) (*SentLog, error) {
+	result := &SentLog{path: path, sent: make(map[sentKey]bool)}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+
+			// A crash can leave a partial last line. Ignore it.
+			continue
+		}
+		result.add(&entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+	}
+	return result, nil
+}
+
+// Sent returns true if the reminder for the milestone with key and lead
+// was delivered over channel.
+func (l *SentLog) Sent(channel, key, lead string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sent[sentKey{channel: channel, key: key, lead: lead}]
+}
+
+// Record appends entries to the log.
+func (l *SentLog) Record(entries ...*LogEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		l.add(entry)
+	}
+	return nil
+}
+
+// Entries returns the entries in the log, most recent first.
+func (l *SentLog) Entries() []*LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := slices.Clone(l.entries)
+	slices.Reverse(result)
+	return result
+}
+
+func (l *SentLog) add(entry *LogEntry) {
+	l.entries = append(l.entries, entry)
+	if entry.Succeeded() {
+		key := sentKey{
+			channel: entry.Channel, key: entry.Key, lead: entry.Lead}
+		l.sent[key] = true
+	}
+}
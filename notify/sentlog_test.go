@@ -0,0 +1,95 @@
+package notify_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/notify"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func openLog(t *testing.T, path string) *notify.SentLog {
+	result, err := notify.OpenSentLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSentLog(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "sent.log")
+	log := openLog(t, path)
+	assert.Empty(log.Entries())
+	assert.False(log.Sent("email", "mark-1y0m0w0d-34", ""))
+	assert.NoError(log.Record(
+		&notify.LogEntry{Channel: "email", Key: "mark-1y0m0w0d-34"},
+		&notify.LogEntry{
+			Channel: "webhook", Key: "mark-1y0m0w0d-34", Error: "down"}))
+	assert.True(log.Sent("email", "mark-1y0m0w0d-34", ""))
+	assert.False(log.Sent("webhook", "mark-1y0m0w0d-34", ""))
+
+	// Survives reopening even with a partial last line
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"channel": "ema`)
+	f.Close()
+	log = openLog(t, path)
+	assert.True(log.Sent("email", "mark-1y0m0w0d-34", ""))
+	assert.False(log.Sent("webhook", "mark-1y0m0w0d-34", ""))
+	entries := log.Entries()
+	if assert.Len(entries, 2) {
+		assert.Equal("webhook", entries[0].Channel)
+		assert.False(entries[0].Succeeded())
+		assert.Equal("email", entries[1].Channel)
+		assert.True(entries[1].Succeeded())
+	}
+}
+
+func TestNotifyWithLog(t *testing.T) {
+	assert := asserts.New(t)
+	path := filepath.Join(t.TempDir(), "sent.log")
+	email := &fakeSink{name: "email"}
+	webhook := &fakeSink{name: "webhook", err: errors.New("down")}
+	notifier := newNotifier(email, webhook)
+	notifier.Log = openLog(t, path)
+	assert.EqualError(notifier.Notify(), "webhook: down")
+
+	// A restart with overlapping lead times
+	notifier.Log = openLog(t, path)
+	notifier.Clock = fixedClock(time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC))
+	notifier.DaysAhead = 7
+	webhook.err = nil
+	assert.NoError(notifier.Notify())
+
+	if assert.Len(email.digests, 2) {
+		assert.Len(email.digests[0].Milestones, 2)
+
+		// Ann Jones went out yesterday
+		if assert.Len(email.digests[1].Milestones, 1) {
+			assert.Equal(
+				"Bob Jones", email.digests[1].Milestones[0].EntryPtr.Name)
+		}
+	}
+	if assert.Len(webhook.digests, 2) {
+
+		// Ann Jones failed yesterday, so try again
+		assert.Len(webhook.digests[1].Milestones, 2)
+	}
+	entries := notifier.Log.Entries()
+	assert.Len(entries, 7)
+	assert.Equal("Bob Jones", entries[0].Name)
+	assert.Equal("2024-03-09", entries[0].Date)
+	assert.Equal(
+		time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), entries[0].Time)
+
+	// Nothing new
+	assert.NoError(notifier.Notify())
+	assert.Len(email.digests, 2)
+	assert.Len(webhook.digests, 2)
+}
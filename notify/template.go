@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"bytes"
+	htemplate "html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/keep94/birthday"
+)
+
+const (
+	kDefaultSubject = "Upcoming special days"
+)
+
+var (
+	kFuncs = map[string]any{
+		"ordinal":     Ordinal,
+		"date":        birthday.ToString,
+		"weekdayDate": birthday.ToStringWithWeekDay,
+		"upper":       strings.ToUpper,
+		"lower":       strings.ToLower,
+	}
+
+	kDefaultText = template.Must(template.New("text").Funcs(kFuncs).Parse(
+		`Upcoming special days as of {{.DateStr .Date}}
+{{range .Milestones}}
+{{$.DateStr .Date}} ({{$.When .}}): {{.EntryPtr.Name}} {{.AgeString}}{{end}}
+`))
+	kDefaultHTML = htemplate.Must(htemplate.New("html").Funcs(kFuncs).Parse(
+		`<html>
+<body>
+  <h1>Upcoming special days</h1>
+  <p>As of {{.DateStr .Date}}</p>
+  <table border=1>
+    <tr>
+      <th>Date</th>
+      <th>When</th>
+      <th>Name</th>
+      <th>Age</th>
+    </tr>
+    {{range .Milestones}}
+    <tr>
+      <td>{{$.DateStr .Date}}</td>
+      <td>{{$.When .}}</td>
+      <td>{{.EntryPtr.Name}}</td>
+      <td>{{.AgeString}}</td>
+    </tr>
+    {{end}}
+  </table>
+</body>
+</html>
+`))
+)
+
+// Message is a rendered notification.
+type Message struct {
+	Subject string
+
+	// The plain text body
+	Text string
+
+	// The HTML body. Empty if there is none.
+	HTML string
+}
+
+// Renderer is implemented by sinks that render digests into messages.
+type Renderer interface {
+
+	// Render returns the message that would go out for digest.
+	Render(digest *Digest) (*Message, error)
+}
+
+// Templates renders digests into messages. Templates written for
+// text/template and html/template execute against the digest. Besides
+// the fields of Digest, templates can use these methods of the digest:
+//
+//	{{.DateStr .Date}}       the date with the weekday e.g "Fri 03/01/2024"
+//	{{$.When $milestone}}    e.g "today", "tomorrow" or "in 7 days"
+//	{{$.DaysUntil $milestone}}
+//
+// Each milestone has the fields of birthday.Milestone, so
+// {{.EntryPtr.Name}}, {{.EntryPtr.Birthday}}, {{.Count}} and {{.Date}}
+// along with the {{.AgeString}} method. Templates can also call these
+// functions:
+//
+//	ordinal     1 becomes "1st", 22 becomes "22nd" and so on
+//	date        a date as MM/dd/yyyy, or MM/dd without a year
+//	weekdayDate a date with its weekday
+//	upper       upper case
+//	lower       lower case
+//
+// The zero value renders the default subject and the built-in plain
+// text and HTML bodies.
+type Templates struct {
+
+	// nil means the default subject.
+	Subject *template.Template
+
+	// nil means the built-in plain text body.
+	Text *template.Template
+
+	// nil means the built-in HTML body.
+	HTML *htemplate.Template
+}
+
+// ParseTemplateFiles reads templates from files. An empty path means use
+// the default for that part of the message.
+func ParseTemplateFiles(subjectPath, textPath, htmlPath string) (
+	*Templates, error) {
+	var result Templates
+	var err error
+	if subjectPath != "" {
+		if result.Subject, err = parseTextFile(subjectPath); err != nil {
+			return nil, err
+		}
+	}
+	if textPath != "" {
+		if result.Text, err = parseTextFile(textPath); err != nil {
+			return nil, err
+		}
+	}
+	if htmlPath != "" {
+		contents, err := os.ReadFile(htmlPath)
+		if err != nil {
+			return nil, err
+		}
+		result.HTML, err = htemplate.New(filepath.Base(htmlPath)).
+			Funcs(kFuncs).Parse(string(contents))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
+
+// Render renders digest. A nil Templates renders the same as the zero
+// value.
+func (t *Templates) Render(digest *Digest) (*Message, error) {
+	if t == nil {
+		t = &Templates{}
+	}
+	view := &digestView{Digest: digest}
+	var result Message
+	if t.Subject == nil {
+		result.Subject = kDefaultSubject
+	} else {
+		subject, err := executeText(t.Subject, view)
+		if err != nil {
+			return nil, err
+		}
+
+		// Headers can't span lines.
+		result.Subject = strings.Join(strings.Fields(subject), " ")
+	}
+	text := t.Text
+	if text == nil {
+		text = kDefaultText
+	}
+	var err error
+	if result.Text, err = executeText(text, view); err != nil {
+		return nil, err
+	}
+	html := t.HTML
+	if html == nil {
+		html = kDefaultHTML
+	}
+	var buf bytes.Buffer
+	if err := html.Execute(&buf, view); err != nil {
+		return nil, err
+	}
+	result.HTML = buf.String()
+	return &result, nil
+}
+
+// Ordinal returns n as an English ordinal number e.g "1st", "2nd",
+// "3rd", "11th", "22nd".
+func Ordinal(n int) string {
+	suffix := "th"
+	switch n % 100 {
+	case 11, 12, 13:
+	default:
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func parseTextFile(path string) (*template.Template, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Funcs(kFuncs).Parse(
+		string(contents))
+}
+
+func executeText(t *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+type digestView struct {
+	*Digest
+}
+
+func (v *digestView) DateStr(t time.Time) string {
+	return birthday.ToStringWithWeekDay(t)
+}
@@ -0,0 +1,113 @@
+package notify_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keep94/birthday/notify"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTemplates(t *testing.T) {
+	assert := asserts.New(t)
+	dir := t.TempDir()
+	templates, err := notify.ParseTemplateFiles(
+		writeFile(t, dir, "subject.txt",
+			"{{len .Milestones}} Geburtstage\nab {{date .Date}}"),
+		writeFile(t, dir, "text.txt",
+			`{{range .Milestones}}{{upper .EntryPtr.Name}}: {{ordinal .Count}} `+
+				`{{$.When .}} ({{$.DaysUntil .}}) {{.AgeString}}
+{{end}}`),
+		writeFile(t, dir, "body.html",
+			`{{range .Milestones}}<b>{{.EntryPtr.Name}}</b>{{end}}`))
+	if !assert.NoError(err) {
+		return
+	}
+	digest, err := newNotifier().Digest()
+	if !assert.NoError(err) {
+		return
+	}
+	digest.Milestones[0].EntryPtr.Name = "Mark <Smith>"
+	message, err := templates.Render(digest)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("2 Geburtstage ab 03/01/2024", message.Subject)
+	assert.Equal(
+		"MARK <SMITH>: 34th today (0) 34 years\n"+
+			"ANN JONES: 39th in 3 days (3) 39 years\n",
+		message.Text)
+	assert.Equal(
+		"<b>Mark &lt;Smith&gt;</b><b>Ann Jones</b>", message.HTML)
+}
+
+func TestTemplatesDefault(t *testing.T) {
+	assert := asserts.New(t)
+	digest, err := newNotifier().Digest()
+	if !assert.NoError(err) {
+		return
+	}
+	var templates *notify.Templates
+	message, err := templates.Render(digest)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("Upcoming special days", message.Subject)
+	assert.Contains(
+		message.Text, "Mon 03/04/2024 (in 3 days): Ann Jones 39 years")
+	assert.Contains(message.HTML, "<td>Ann Jones</td>")
+}
+
+func TestTemplatesBadFile(t *testing.T) {
+	assert := asserts.New(t)
+	dir := t.TempDir()
+	_, err := notify.ParseTemplateFiles(
+		"", writeFile(t, dir, "text.txt", "{{.Date"), "")
+	assert.Error(err)
+	_, err = notify.ParseTemplateFiles(
+		"", "", filepath.Join(dir, "missing.html"))
+	assert.Error(err)
+}
+
+func TestEmailSubjectTemplate(t *testing.T) {
+	assert := asserts.New(t)
+	templates, err := notify.ParseTemplateFiles(
+		writeFile(t, t.TempDir(), "subject.txt", "{{$.When (index .Milestones 1)}}"),
+		"",
+		"")
+	if !assert.NoError(err) {
+		return
+	}
+	email := &notify.Email{Subject: "ignored", Templates: templates}
+	digest, _ := newNotifier().Digest()
+	message, err := email.Render(digest)
+	assert.NoError(err)
+	assert.Equal("in 3 days", message.Subject)
+	email.Templates = nil
+	message, err = email.Render(digest)
+	assert.NoError(err)
+	assert.Equal("ignored", message.Subject)
+}
+
+func TestOrdinal(t *testing.T) {
+	assert := asserts.New(t)
+	assert.Equal("1st", notify.Ordinal(1))
+	assert.Equal("2nd", notify.Ordinal(2))
+	assert.Equal("3rd", notify.Ordinal(3))
+	assert.Equal("4th", notify.Ordinal(4))
+	assert.Equal("11th", notify.Ordinal(11))
+	assert.Equal("12th", notify.Ordinal(12))
+	assert.Equal("13th", notify.Ordinal(13))
+	assert.Equal("21st", notify.Ordinal(21))
+	assert.Equal("102nd", notify.Ordinal(102))
+	assert.Equal("111th", notify.Ordinal(111))
+}
@@ -0,0 +1,246 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+)
+
+const (
+	kDefaultWebhookTimeout = 10 * time.Second
+	kDefaultRetryDelay     = time.Second
+)
+
+// PayloadFormat is the format of the JSON a Webhook posts.
+type PayloadFormat int
+
+const (
+
+	// Generic posts the date and the milestones as structured JSON.
+	Generic PayloadFormat = iota
+
+	// Slack posts a message in the incoming webhook format that Slack
+	// and Mattermost accept.
+	Slack
+)
+
+// ParsePayloadFormat converts "generic", "slack", or "mattermost" to a
+// PayloadFormat. Empty string means Generic.
+func ParsePayloadFormat(s string) (PayloadFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "generic":
+		return Generic, nil
+	case "slack", "mattermost":
+		return Slack, nil
+	default:
+		return 0, fmt.Errorf("unknown webhook format %q", s)
+	}
+}
+
+// Webhook posts digests as JSON to a URL.
+type Webhook struct {
+	URL string
+
+	Format PayloadFormat
+
+	// If non-empty, each request has an X-Signature-256 header of
+	// "sha256=" followed by the hex HMAC-SHA256 of the request body
+	// keyed with Secret.
+	Secret string
+
+	// How long to wait for each attempt. The default is 10 seconds.
+	Timeout time.Duration
+
+	// How many more times to try after a network error or a 5xx or 429
+	// response. Other responses are not retried.
+	Retries int
+
+	// How long to wait before the first retry. The wait doubles for each
+	// retry after that. The default is 1 second.
+	RetryDelay time.Duration
+
+	// If non-nil and it has a Text template, Slack payloads use it for
+	// their text.
+	Templates *Templates
+
+	// Optional. Overrides the name of this sink. See Sink.Name.
+	Channel string
+}
+
+// Name returns Channel or, if Channel is empty, "webhook" followed by the
+// host of the URL. Name leaves out the rest of the URL as webhook URLs
+// often contain secrets.
+func (h *Webhook) Name() string {
+	if h.Channel != "" {
+		return h.Channel
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return "webhook " + u.Host
+}
+
+// Send posts digest to the URL.
+func (h *Webhook) Send(digest *Digest) error {
+	body, err := h.Payload(digest)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: h.Timeout}
+	if client.Timeout == 0 {
+		client.Timeout = kDefaultWebhookTimeout
+	}
+	delay := h.RetryDelay
+	if delay == 0 {
+		delay = kDefaultRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		retry, err := h.post(client, body)
+		if err == nil || !retry || attempt >= h.Retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Payload returns the JSON that Send posts for digest.
+func (h *Webhook) Payload(digest *Digest) ([]byte, error) {
+	switch h.Format {
+	case Slack:
+		text, err := h.slackText(digest)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&slackPayload{Text: text})
+	default:
+		return json.Marshal(newGenericPayload(digest))
+	}
+}
+
+// Render returns the text of the Slack message for digest or the JSON
+// payload for the Generic format.
+func (h *Webhook) Render(digest *Digest) (*Message, error) {
+	if h.Format == Slack {
+		text, err := h.slackText(digest)
+		if err != nil {
+			return nil, err
+		}
+		return &Message{Text: text}, nil
+	}
+	payload, err := json.MarshalIndent(newGenericPayload(digest), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Text: string(payload)}, nil
+}
+
+func (h *Webhook) slackText(digest *Digest) (string, error) {
+	if h.Templates == nil || h.Templates.Text == nil {
+		return slackText(digest), nil
+	}
+	return executeText(h.Templates.Text, &digestView{Digest: digest})
+}
+
+// post posts body once. post reports whether a failure is worth
+// retrying.
+func (h *Webhook) post(client *http.Client, body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.Secret != "" {
+		req.Header.Set("X-Signature-256", Sign(h.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 == 2 {
+		return false, nil
+	}
+	retry := resp.StatusCode/100 == 5 ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("got status %s", resp.Status)
+}
+
+// Sign returns the X-Signature-256 header value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func slackText(digest *Digest) string {
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		"*Upcoming special days as of %s*",
+		birthday.ToStringWithWeekDay(digest.Date))
+	for _, m := range digest.Milestones {
+		fmt.Fprintf(
+			&b,
+			"\n• %s (%s): %s %s",
+			birthday.ToStringWithWeekDay(m.Date),
+			digest.When(m),
+			m.EntryPtr.Name,
+			m.AgeString())
+	}
+	return b.String()
+}
+
+type genericPayload struct {
+	Date       string             `json:"date"`
+	Milestones []genericMilestone `json:"milestones"`
+}
+
+type genericMilestone struct {
+	Date      string `json:"date"`
+	DaysUntil int    `json:"daysUntil"`
+	When      string `json:"when"`
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Age       string `json:"age"`
+}
+
+func newGenericPayload(digest *Digest) *genericPayload {
+	result := &genericPayload{
+		Date:       digest.Date.Format(time.DateOnly),
+		Milestones: []genericMilestone{},
+	}
+	for _, m := range digest.Milestones {
+		result.Milestones = append(
+			result.Milestones, newGenericMilestone(digest, m))
+	}
+	return result
+}
+
+func newGenericMilestone(
+	digest *Digest, m *birthday.Milestone) genericMilestone {
+	return genericMilestone{
+		Date:      m.Date.Format(time.DateOnly),
+		DaysUntil: digest.DaysUntil(m),
+		When:      digest.When(m),
+		Id:        m.EntryPtr.Id,
+		Name:      m.EntryPtr.Name,
+		Age:       m.AgeString(),
+	}
+}
@@ -0,0 +1,176 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/notify"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+// newWebhookServer returns a server that responds with statuses in turn
+// and with 200 after that.
+func newWebhookServer(statuses ...int) *webhookServer {
+	result := &webhookServer{statuses: statuses}
+	result.Server = httptest.NewServer(http.HandlerFunc(result.serve))
+	return result
+}
+
+func (s *webhookServer) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	s.headers = append(s.headers, r.Header)
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status = s.statuses[0]
+		s.statuses = s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestWebhookGeneric(t *testing.T) {
+	assert := asserts.New(t)
+	server := newWebhookServer()
+	defer server.Close()
+	hook := &notify.Webhook{URL: server.URL + "/hook", Secret: "shh"}
+	assert.NoError(newNotifier(hook).Notify())
+	if !assert.Len(server.bodies, 1) {
+		return
+	}
+	body := server.bodies[0]
+	assert.Equal("application/json", server.headers[0].Get("Content-Type"))
+	assert.Equal(
+		notify.Sign("shh", body), server.headers[0].Get("X-Signature-256"))
+	assert.True(
+		strings.HasPrefix(server.headers[0].Get("X-Signature-256"), "sha256="))
+	var payload struct {
+		Date       string
+		Milestones []struct {
+			Date      string
+			DaysUntil int
+			When      string
+			Id        string
+			Name      string
+		}
+	}
+	assert.NoError(json.Unmarshal(body, &payload))
+	assert.Equal("2024-03-01", payload.Date)
+	if assert.Len(payload.Milestones, 2) {
+		assert.Equal("2024-03-04", payload.Milestones[1].Date)
+		assert.Equal(3, payload.Milestones[1].DaysUntil)
+		assert.Equal("in 3 days", payload.Milestones[1].When)
+		assert.Equal("ann", payload.Milestones[1].Id)
+		assert.Equal("Ann Jones", payload.Milestones[1].Name)
+	}
+}
+
+func TestWebhookSlack(t *testing.T) {
+	assert := asserts.New(t)
+	server := newWebhookServer()
+	defer server.Close()
+	hook := &notify.Webhook{URL: server.URL, Format: notify.Slack}
+	assert.NoError(newNotifier(hook).Notify())
+	if !assert.Len(server.bodies, 1) {
+		return
+	}
+	assert.Empty(server.headers[0].Get("X-Signature-256"))
+	var payload struct {
+		Text string
+	}
+	assert.NoError(json.Unmarshal(server.bodies[0], &payload))
+	assert.Contains(payload.Text, "(today): Mark Smith")
+	assert.Contains(payload.Text, "(in 3 days): Ann Jones")
+}
+
+func TestWebhookRetries(t *testing.T) {
+	assert := asserts.New(t)
+	server := newWebhookServer(
+		http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	defer server.Close()
+	hook := &notify.Webhook{
+		URL: server.URL, Retries: 2, RetryDelay: time.Millisecond}
+	assert.NoError(newNotifier(hook).Notify())
+	assert.Len(server.bodies, 3)
+}
+
+func TestWebhookGivesUp(t *testing.T) {
+	assert := asserts.New(t)
+	server := newWebhookServer(
+		http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	defer server.Close()
+	hook := &notify.Webhook{
+		URL: server.URL, Retries: 1, RetryDelay: time.Millisecond}
+	err := newNotifier(hook).Notify()
+	assert.ErrorContains(err, "502")
+	assert.ErrorContains(err, "webhook 127.0.0.1")
+	assert.Len(server.bodies, 2)
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	assert := asserts.New(t)
+	server := newWebhookServer(http.StatusNotFound)
+	defer server.Close()
+	hook := &notify.Webhook{
+		URL: server.URL, Retries: 3, RetryDelay: time.Millisecond}
+	assert.ErrorContains(newNotifier(hook).Notify(), "404")
+	assert.Len(server.bodies, 1)
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	assert := asserts.New(t)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+	defer server.Close()
+	defer close(release)
+	hook := &notify.Webhook{URL: server.URL, Timeout: 50 * time.Millisecond}
+	assert.Error(newNotifier(hook).Notify())
+}
+
+func TestParsePayloadFormat(t *testing.T) {
+	assert := asserts.New(t)
+	format, err := notify.ParsePayloadFormat("")
+	assert.NoError(err)
+	assert.Equal(notify.Generic, format)
+	format, err = notify.ParsePayloadFormat("Mattermost")
+	assert.NoError(err)
+	assert.Equal(notify.Slack, format)
+	_, err = notify.ParsePayloadFormat("teams")
+	assert.Error(err)
+}
+
+func TestWebhookSlackTemplate(t *testing.T) {
+	assert := asserts.New(t)
+	templates, err := notify.ParseTemplateFiles(
+		"",
+		writeFile(t, t.TempDir(), "slack.txt",
+			"{{range .Milestones}}:cake: {{.EntryPtr.Name}} {{end}}"),
+		"")
+	if !assert.NoError(err) {
+		return
+	}
+	hook := &notify.Webhook{Format: notify.Slack, Templates: templates}
+	digest, _ := newNotifier().Digest()
+	payload, err := hook.Payload(digest)
+	assert.NoError(err)
+	assert.JSONEq(
+		`{"text": ":cake: Mark Smith :cake: Ann Jones "}`, string(payload))
+}
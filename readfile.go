@@ -2,12 +2,26 @@ package birthday
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/str_util"
+)
+
+const (
+	kIdPrefix = "id="
+)
+
+var (
+	errInvalidId       = errors.New("contains invalid id")
+	errMalformatted    = errors.New("malformatted")
+	errInvalidBirthday = errors.New("contains invalid birthday")
 )
 
 // Interface Store abstracts away reading the birthday file for testability.
@@ -36,28 +50,27 @@ func ReadFile(filename string, consumer consume2.Consumer[Entry]) error {
 }
 
 // Read reads a birthday file. consumer consumes the Entry instances read.
+// Each line of a birthday file may have an optional id=<id> field after
+// the birthday which gives the Id for the entry on that line. Otherwise
+// the Id is derived from the name and birthday. Read returns an error if
+// two entries have the same Id.
 func Read(r io.Reader, consumer consume2.Consumer[Entry]) error {
 	scanner := bufio.NewScanner(r)
 	lineNo := 0
+	ids := make(map[string]bool)
 	for scanner.Scan() && consumer.CanConsume() {
-		var entry Entry
 		lineNo++
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+		entry, ok, err := parseLine(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("Line %d %v", lineNo, err)
 		}
-		parts := strings.Split(line, "\t")
-		if len(parts) < 2 {
-			return fmt.Errorf("Line %d malformatted", lineNo)
+		if !ok {
+			continue
 		}
-		entry.Name = strings.TrimSpace(parts[0])
-		bdayStr := strings.TrimSpace(parts[1])
-		var err error
-		entry.Birthday, err = Parse(bdayStr)
-		if err != nil {
-			return fmt.Errorf("Line %d contains invalid birthday", lineNo)
+		if ids[entry.Id] {
+			return fmt.Errorf("Line %d has duplicate id %s", lineNo, entry.Id)
 		}
+		ids[entry.Id] = true
 		consumer.Consume(entry)
 	}
 	if err := scanner.Err(); err != nil {
@@ -65,3 +78,54 @@ func Read(r io.Reader, consumer consume2.Consumer[Entry]) error {
 	}
 	return nil
 }
+
+// parseLine parses a single line of a birthday file. If line is blank or
+// a comment, parseLine returns ok = false.
+func parseLine(line string) (entry Entry, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	parts := strings.Split(line, "\t")
+	if len(parts) < 2 {
+		err = errMalformatted
+		return
+	}
+	entry.Name = strings.TrimSpace(parts[0])
+	entry.Birthday, err = Parse(strings.TrimSpace(parts[1]))
+	if err != nil {
+		err = errInvalidBirthday
+		return
+	}
+	entry.Id, ok = explicitId(parts[2:])
+	if !ok {
+		entry.Id = DeriveId(entry)
+	}
+	if !validId(entry.Id) {
+		err = errInvalidId
+		return
+	}
+	return entry, true, nil
+}
+
+// explicitId returns the id from the id=<id> field in extra fields.
+func explicitId(extra []string) (string, bool) {
+	for _, field := range extra {
+		field = strings.TrimSpace(field)
+		if id, ok := strings.CutPrefix(field, kIdPrefix); ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func validId(id string) bool {
+	return id != "" && !strings.ContainsAny(id, " \t\r\n")
+}
+
+// DeriveId returns the Id of entry derived from its name and birthday.
+func DeriveId(entry Entry) string {
+	sum := sha256.Sum256(
+		[]byte(str_util.Normalize(entry.Name) + "\t" + ToString(entry.Birthday)))
+	return hex.EncodeToString(sum[:6])
+}
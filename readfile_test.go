@@ -69,10 +69,12 @@ Alice Doe	12/15
 		{
 			Name:     "Jack Sprat",
 			Birthday: date_util.YMD(2006, 8, 31),
+			Id:       "ebb25728440b",
 		},
 		{
 			Name:     "Alice Doe",
 			Birthday: date_util.YMD(0, 12, 15),
+			Id:       "3fa54e4cfbef",
 		},
 	}, entries)
 }
@@ -95,6 +97,7 @@ Alice Doe	12/15
 		{
 			Name:     "Jack Sprat",
 			Birthday: date_util.YMD(2006, 8, 31),
+			Id:       "ebb25728440b",
 		},
 	}, entries)
 }
@@ -116,10 +119,56 @@ func TestReadLinesWithWhitespace(t *testing.T) {
 		{
 			Name:     "Jack Sprat",
 			Birthday: date_util.YMD(2006, 8, 31),
+			Id:       "ebb25728440b",
 		},
 		{
 			Name:     "Alice Doe",
 			Birthday: date_util.YMD(0, 12, 15),
+			Id:       "3fa54e4cfbef",
 		},
 	}, entries)
 }
+
+func TestReadExplicitIds(t *testing.T) {
+	assert := asserts.New(t)
+
+	fileContents := `
+John Smith	03/25/1967	id=john1
+John Smith	03/25/1967	Cousin	id=john2
+John Smith	03/25/1967
+`
+	var entries []birthday.Entry
+	err := birthday.Read(
+		strings.NewReader(fileContents), consume2.AppendTo(&entries))
+	assert.NoError(err)
+	assert.Len(entries, 3)
+	assert.Equal("john1", entries[0].Id)
+	assert.Equal("john2", entries[1].Id)
+	assert.Equal("31a94b7631df", entries[2].Id)
+}
+
+func TestReadDuplicateIds(t *testing.T) {
+	assert := asserts.New(t)
+
+	fileContents := `
+John Smith	03/25/1967
+Bill Shaw	12/07/1973
+john  smith	3/25/1967
+`
+	var entries []birthday.Entry
+	err := birthday.Read(
+		strings.NewReader(fileContents), consume2.AppendTo(&entries))
+	assert.EqualError(err, "Line 4 has duplicate id 31a94b7631df")
+}
+
+func TestReadBadId(t *testing.T) {
+	assert := asserts.New(t)
+
+	fileContents := `
+John Smith	03/25/1967	id=
+`
+	var entries []birthday.Entry
+	err := birthday.Read(
+		strings.NewReader(fileContents), consume2.AppendTo(&entries))
+	assert.EqualError(err, "Line 2 contains invalid id")
+}
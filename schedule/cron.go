@@ -0,0 +1,196 @@
+// Package schedule runs jobs at times given by cron expressions.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Next gives up looking for a matching time after this many years.
+	kMaxYears = 5
+)
+
+var (
+	kShortcuts = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr    string
+	minutes uint64
+	hours   uint64
+	days    uint64
+	months  uint64
+	weekday uint64
+
+	// true if the day of month or day of week field is *
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Parse parses a standard 5 field cron expression: minute, hour, day of
+// month, month, and day of week. Each field is *, a number, a range such
+// as 1-5, or a comma separated list of these. Any of these can have a
+// step such as */15 or 8-18/2. Day of week runs from 0 (Sunday) to 6;
+// 7 is also Sunday. As with cron, if both day of month and day of week
+// are given, a time matches if either matches. Parse also accepts
+// @yearly, @monthly, @weekly, @daily and @hourly.
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 1 {
+		if full, ok := kShortcuts[fields[0]]; ok {
+			fields = strings.Fields(full)
+		}
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q needs 5 fields", expr)
+	}
+	result := &Schedule{
+		expr:       expr,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	var err error
+	if result.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron expression %q: minute: %w", expr, err)
+	}
+	if result.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron expression %q: hour: %w", expr, err)
+	}
+	if result.days, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf(
+			"cron expression %q: day of month: %w", expr, err)
+	}
+	if result.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron expression %q: month: %w", expr, err)
+	}
+	if result.weekday, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf(
+			"cron expression %q: day of week: %w", expr, err)
+	}
+	if result.weekday&(1<<7) != 0 {
+		result.weekday |= 1
+	}
+	return result, nil
+}
+
+// String returns the cron expression of this schedule.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t that matches this schedule in the
+// location of t. Next returns the zero time if no such time exists such
+// as for February 30.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(kMaxYears, 0, 0)
+	for t.Before(limit) {
+		if !has(s.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.hours, t.Hour()) {
+			t = time.Date(
+				t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(s.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := has(s.days, t.Day())
+	weekday := has(s.weekday, int(t.Weekday()))
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func has(bits uint64, x int) bool {
+	return bits&(1<<uint(x)) != 0
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(field, ",") {
+		bits, err := parsePart(part, min, max)
+		if err != nil {
+			return 0, err
+		}
+		result |= bits
+	}
+	return result, nil
+}
+
+func parsePart(part string, min, max int) (uint64, error) {
+	rangeStr, stepStr, hasStep := strings.Cut(part, "/")
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepStr)
+		if err != nil || step <= 0 {
+			return 0, fmt.Errorf("bad step %q", stepStr)
+		}
+	}
+	var lo, hi int
+	if rangeStr == "*" {
+		lo, hi = min, max
+	} else {
+		loStr, hiStr, isRange := strings.Cut(rangeStr, "-")
+		var err error
+		if lo, err = parseNumber(loStr, min, max); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if isRange {
+			if hi, err = parseNumber(hiStr, min, max); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			hi = max
+		}
+		if hi < lo {
+			return 0, fmt.Errorf("bad range %q", rangeStr)
+		}
+	}
+	var result uint64
+	for i := lo; i <= hi; i += step {
+		result |= 1 << uint(i)
+	}
+	return result, nil
+}
+
+func parseNumber(s string, min, max int) (int, error) {
+	result, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("bad number " + strconv.Quote(s))
+	}
+	if result < min || result > max {
+		return 0, fmt.Errorf("%d not between %d and %d", result, min, max)
+	}
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package schedule_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/keep94/birthday/schedule"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func mustParse(t *testing.T, expr string) *schedule.Schedule {
+	result, err := schedule.Parse(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestNext(t *testing.T) {
+	assert := asserts.New(t)
+	start := time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC)
+	assert.Equal(
+		time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+		mustParse(t, "0 8 * * *").Next(start))
+	assert.Equal(
+		time.Date(2026, 10, 18, 8, 45, 0, 0, time.UTC),
+		mustParse(t, "*/15 * * * *").Next(start))
+	assert.Equal(
+		time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC),
+		mustParse(t, "0 6-18/4 * * *").Next(start))
+
+	// 2026-10-18 is a Sunday
+	assert.Equal(
+		time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC),
+		mustParse(t, "0 7 * * 1-5").Next(start))
+	assert.Equal(
+		time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		mustParse(t, "@weekly").Next(start))
+	assert.Equal(
+		time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		mustParse(t, "0 0 * * 7").Next(start))
+	assert.Equal(
+		time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		mustParse(t, "@yearly").Next(start))
+	assert.Equal(
+		time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+		mustParse(t, "0 12 29 2 *").Next(start))
+
+	// Day of month or day of week: the 20th or any Monday
+	assert.Equal(
+		time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		mustParse(t, "0 0 20 * 1").Next(start))
+
+	assert.True(mustParse(t, "0 0 30 2 *").Next(start).IsZero())
+}
+
+func TestNextTimeZone(t *testing.T) {
+	assert := asserts.New(t)
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	s := mustParse(t, "0 8 * * *")
+
+	// Clocks fall back on 2026-11-01
+	next := s.Next(time.Date(2026, 10, 31, 9, 0, 0, 0, ny))
+	assert.Equal(time.Date(2026, 11, 1, 8, 0, 0, 0, ny), next)
+	assert.Equal(time.Date(2026, 11, 1, 13, 0, 0, 0, time.UTC), next.UTC())
+	next = s.Next(next)
+	assert.Equal(time.Date(2026, 11, 2, 13, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestParseErrors(t *testing.T) {
+	assert := asserts.New(t)
+	for _, expr := range []string{
+		"",
+		"0 8 * *",
+		"0 8 * * * *",
+		"60 8 * * *",
+		"0 24 * * *",
+		"0 8 0 * *",
+		"0 8 * 13 *",
+		"0 8 * * 8",
+		"0 8-6 * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		_, err := schedule.Parse(expr)
+		assert.Error(err, expr)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	assert := asserts.New(t)
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 7, 59, 0, 0, time.UTC)}
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	var runs int
+	newScheduler := func() *schedule.Scheduler {
+		return &schedule.Scheduler{
+			Jobs: []*schedule.Job{
+				{
+					Name:     "digest",
+					Schedule: mustParse(t, "0 8 * * *"),
+					Run:      func() error { runs++; return nil },
+				},
+			},
+			StateFile: stateFile,
+			Clock:     clock,
+		}
+	}
+	scheduler := newScheduler()
+	assert.NoError(scheduler.Tick())
+	assert.Equal(0, runs)
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(scheduler.Tick())
+	assert.Equal(1, runs)
+	clock.now = clock.now.Add(time.Minute)
+	assert.NoError(scheduler.Tick())
+	assert.Equal(1, runs)
+
+	// A restart the same day doesn't run the job again
+	scheduler = newScheduler()
+	assert.NoError(scheduler.Tick())
+	assert.Equal(1, runs)
+
+	// Down at 8:00 the next day, but back up at 11:00
+	clock.now = time.Date(2026, 10, 19, 11, 0, 0, 0, time.UTC)
+	scheduler = newScheduler()
+	assert.NoError(scheduler.Tick())
+	assert.Equal(2, runs)
+	assert.NoError(scheduler.Tick())
+	assert.Equal(2, runs)
+
+	// Down for all of the 20th. Up again just before 8:00 on the 21st.
+	clock.now = time.Date(2026, 10, 21, 7, 0, 0, 0, time.UTC)
+	scheduler = newScheduler()
+	assert.NoError(scheduler.Tick())
+	assert.Equal(2, runs)
+}
+
+func TestSchedulerTimeZone(t *testing.T) {
+	assert := asserts.New(t)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// 8:00 in Tokyo
+	clock := &fakeClock{now: time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)}
+	var runs int
+	scheduler := &schedule.Scheduler{
+		Jobs: []*schedule.Job{
+			{
+				Name:     "digest",
+				Schedule: mustParse(t, "0 8 * * *"),
+				Run:      func() error { runs++; return nil },
+			},
+		},
+		Location: tokyo,
+		Clock:    clock,
+	}
+	assert.NoError(scheduler.Tick())
+	assert.Equal(1, runs)
+}
+
+func TestSchedulerError(t *testing.T) {
+	assert := asserts.New(t)
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)}
+	var runs int
+	scheduler := &schedule.Scheduler{
+		Jobs: []*schedule.Job{
+			{
+				Name:     "broken",
+				Schedule: mustParse(t, "0 8 * * *"),
+				Run:      func() error { return errors.New("oops") },
+			},
+			{
+				Name:     "working",
+				Schedule: mustParse(t, "0 8 * * *"),
+				Run:      func() error { runs++; return nil },
+			},
+		},
+		Clock: clock,
+	}
+	err := scheduler.Tick()
+	assert.ErrorContains(err, "broken: oops")
+	assert.Equal(1, runs)
+
+	// Failed jobs wait until their next scheduled time.
+	assert.NoError(scheduler.Tick())
+	assert.Equal(1, runs)
+}
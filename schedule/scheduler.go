@@ -0,0 +1,155 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/keep94/birthday/internal/atomicfile"
+	"github.com/keep94/toolbox/date_util"
+)
+
+// Job is a task that runs on a schedule.
+type Job struct {
+
+	// Identifies this job in the state file. Must be unique within a
+	// Scheduler.
+	Name string
+
+	Schedule *Schedule
+
+	Run func() error
+}
+
+// Scheduler runs jobs when their schedules say to. A Scheduler records
+// in a state file when each job last ran so that restarting the program
+// does not run a job twice for the same scheduled time. If the program
+// is down when a job should run, the job runs as soon as the program
+// comes back up, but only if that happens the same day. Scheduler never
+// makes up for runs missed on earlier days.
+type Scheduler struct {
+	Jobs []*Job
+
+	// The time zone of the schedules. nil means UTC.
+	Location *time.Location
+
+	// Where to record when jobs last ran. Empty means don't record, in
+	// which case jobs may run a second time after a restart.
+	StateFile string
+
+	Clock date_util.Clock
+
+	mu     sync.Mutex
+	loaded bool
+	state  map[string]time.Time
+}
+
+// Tick runs each job that is due as of now. A job is due if one of its
+// scheduled times falls between when it last ran, or the start of today
+// if later, and now. A due job runs once no matter how many of its
+// scheduled times it missed. Tick returns the errors of the jobs that
+// failed. A failed job isn't retried until its next scheduled time.
+func (s *Scheduler) Tick() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		state, err := s.readState()
+		if err != nil {
+			return err
+		}
+		s.state = state
+		s.loaded = true
+	}
+	now := s.Clock.Now().In(s.location())
+	startOfDay := time.Date(
+		now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var errs []error
+	for _, job := range s.Jobs {
+		from := startOfDay.Add(-time.Nanosecond)
+		if last, ok := s.state[job.Name]; ok && !last.Before(from) {
+			from = last
+		}
+		due := latest(job.Schedule, from, now)
+		if due.IsZero() {
+			continue
+		}
+		s.state[job.Name] = due
+		if err := s.writeState(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := job.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", job.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run calls Tick every minute until done is closed, logging any errors.
+func (s *Scheduler) Run(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(); err != nil {
+			log.Println(err)
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) location() *time.Location {
+	if s.Location == nil {
+		return time.UTC
+	}
+	return s.Location
+}
+
+func (s *Scheduler) readState() (map[string]time.Time, error) {
+	result := make(map[string]time.Time)
+	if s.StateFile == "" {
+		return result, nil
+	}
+	data, err := os.ReadFile(s.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.StateFile, err)
+	}
+	return result, nil
+}
+
+func (s *Scheduler) writeState() error {
+	if s.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.StateFile, data, 0644, nil)
+}
+
+// latest returns the latest time of schedule after from and no later than
+// now or the zero time if there is none.
+func latest(schedule *Schedule, from, now time.Time) time.Time {
+	var result time.Time
+	t := schedule.Next(from)
+	for !t.IsZero() && !t.After(now) {
+		result = t
+		t = schedule.Next(t)
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+// Package vcard writes birthday entries as vCard 4.0 (RFC 6350) contacts.
+package vcard
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/internal/contentline"
+)
+
+const (
+	kProdId = "-//keep94//birthday//EN"
+)
+
+// Write writes entry to w as a vCard 4.0. The UID of the vCard is the
+// Id of entry qualified by domain. Write writes nothing that varies
+// except entry itself, so the same entry always produces the same vCard.
+func Write(w io.Writer, entry *birthday.Entry, domain string) error {
+	cw := contentline.NewWriter(w)
+	cw.Line("BEGIN", "VCARD")
+	cw.Line("VERSION", "4.0")
+	cw.Line("PRODID", kProdId)
+	cw.Line("UID", contentline.Escape(entry.Id+"@"+domain))
+	cw.Line("FN", contentline.Escape(entry.Name))
+	cw.Line("N", structuredName(entry.Name))
+	cw.Line("BDAY", Bday(entry.Birthday))
+	cw.Line("END", "VCARD")
+	return cw.Flush()
+}
+
+// Bday returns t as a vCard BDAY value. If t has no year, Bday returns
+// the year-less form --MMDD.
+func Bday(t time.Time) string {
+	if !birthday.HasYear(t) {
+		return t.Format("--0102")
+	}
+	return t.Format("20060102")
+}
+
+// structuredName returns the N value for name treating the last word as
+// the family name and the other words as the given name.
+func structuredName(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return ";;;;"
+	}
+	family := words[len(words)-1]
+	given := strings.Join(words[:len(words)-1], " ")
+	return contentline.Escape(family) + ";" + contentline.Escape(given) + ";;;"
+}
@@ -0,0 +1,54 @@
+package vcard_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/birthday/vcard"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	assert := asserts.New(t)
+	var sb strings.Builder
+	err := vcard.Write(
+		&sb,
+		&birthday.Entry{
+			Name:     "Mary Jo Smith",
+			Birthday: date_util.YMD(1967, 3, 25),
+			Id:       "mary",
+		},
+		"example.com")
+	assert.NoError(err)
+	assert.Equal(
+		strings.Join([]string{
+			"BEGIN:VCARD",
+			"VERSION:4.0",
+			"PRODID:-//keep94//birthday//EN",
+			"UID:mary@example.com",
+			"FN:Mary Jo Smith",
+			"N:Smith;Mary Jo;;;",
+			"BDAY:19670325",
+			"END:VCARD",
+			"",
+		}, "\r\n"),
+		sb.String())
+}
+
+func TestWriteNoYear(t *testing.T) {
+	assert := asserts.New(t)
+	var sb strings.Builder
+	err := vcard.Write(
+		&sb,
+		&birthday.Entry{
+			Name:     "Merna",
+			Birthday: date_util.YMD(0, 5, 17),
+			Id:       "merna",
+		},
+		"example.com")
+	assert.NoError(err)
+	assert.Contains(sb.String(), "\r\nN:Merna;;;;\r\n")
+	assert.Contains(sb.String(), "\r\nBDAY:--0517\r\n")
+}
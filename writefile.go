@@ -0,0 +1,182 @@
+package birthday
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/keep94/birthday/internal/atomicfile"
+)
+
+var (
+	// ErrNoSuchId is returned when no entry has the given Id.
+	ErrNoSuchId = errors.New("birthday: no such id")
+
+	// ErrDuplicateEntry is returned when adding an entry that already
+	// exists.
+	ErrDuplicateEntry = errors.New("birthday: entry already exists")
+
+	// ErrConcurrentEdit is returned when the birthday file changes on disk
+	// while it is being updated.
+	ErrConcurrentEdit = errors.New(
+		"birthday: file changed by another process")
+)
+
+var (
+	kWriteMutex sync.Mutex
+)
+
+// Interface WritableStore is a Store that can also be changed.
+type WritableStore interface {
+	Store
+
+	// Add adds entry and returns its Id. The Id field of entry is ignored.
+	Add(entry Entry) (string, error)
+
+	// Update replaces the entry with the given Id with entry. The
+	// updated entry keeps the same Id. The Id field of entry is ignored.
+	Update(id string, entry Entry) error
+
+	// Delete removes the entry with the given Id.
+	Delete(id string) error
+}
+
+// Add adds entry to the end of the birthday file at path s.
+// Add preserves all other lines including comments and blank lines.
+// Add returns ErrDuplicateEntry if the Id derived from entry is already
+// in use.
+func (s SystemStore) Add(entry Entry) (string, error) {
+	if err := checkEntry(entry); err != nil {
+		return "", err
+	}
+	id := DeriveId(entry)
+	err := s.edit(func(lines []string) ([]string, error) {
+		if _, ok := findLine(lines, id); ok {
+			return nil, ErrDuplicateEntry
+		}
+		line := formatLine(entry, nil)
+		if n := len(lines); n > 0 && lines[n-1] == "" {
+			lines[n-1] = line
+			return append(lines, ""), nil
+		}
+		return append(lines, line, ""), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Update replaces the entry with the given Id in the birthday file at
+// path s. Update preserves any extra fields on the replaced line. The
+// updated entry keeps its Id. If the Id would otherwise change because
+// the name or birthday changed, Update records the Id explicitly in an
+// id=<id> field.
+func (s SystemStore) Update(id string, entry Entry) error {
+	if err := checkEntry(entry); err != nil {
+		return err
+	}
+	return s.edit(func(lines []string) ([]string, error) {
+		idx, ok := findLine(lines, id)
+		if !ok {
+			return nil, ErrNoSuchId
+		}
+		extra := extraFields(lines[idx])
+		if _, ok := explicitId(extra); !ok && DeriveId(entry) != id {
+			extra = append(extra, kIdPrefix+id)
+		}
+		lines[idx] = formatLine(entry, extra)
+		return lines, nil
+	})
+}
+
+// Delete removes the entry with the given Id from the birthday file
+// at path s.
+func (s SystemStore) Delete(id string) error {
+	return s.edit(func(lines []string) ([]string, error) {
+		idx, ok := findLine(lines, id)
+		if !ok {
+			return nil, ErrNoSuchId
+		}
+		return append(lines[:idx], lines[idx+1:]...), nil
+	})
+}
+
+// edit applies f to the lines of the birthday file at path s and writes
+// the result back atomically. edit returns ErrConcurrentEdit if the
+// file changes on disk before the new contents are in place.
+func (s SystemStore) edit(f func(lines []string) ([]string, error)) error {
+	kWriteMutex.Lock()
+	defer kWriteMutex.Unlock()
+	path := string(s)
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines, err := f(strings.Split(string(original), "\n"))
+	if err != nil {
+		return err
+	}
+	return writeAtomically(path, original, []byte(strings.Join(lines, "\n")))
+}
+
+// writeAtomically replaces the contents of the file at path with
+// contents. original is what the caller believes the file currently
+// contains.
+func writeAtomically(path string, original, contents []byte) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	return atomicfile.Write(path, contents, 0, func() error {
+		current, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(current, original) {
+			return ErrConcurrentEdit
+		}
+		return nil
+	})
+}
+
+// findLine returns the index of the line holding the entry with given id.
+func findLine(lines []string, id string) (int, bool) {
+	for i, line := range lines {
+		entry, ok, err := parseLine(line)
+		if err == nil && ok && entry.Id == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// extraFields returns the fields after the name and birthday on line.
+func extraFields(line string) []string {
+	parts := strings.Split(strings.TrimSpace(line), "\t")
+	if len(parts) <= 2 {
+		return nil
+	}
+	return parts[2:]
+}
+
+func formatLine(entry Entry, extra []string) string {
+	parts := []string{entry.Name, ToString(entry.Birthday)}
+	return strings.Join(append(parts, extra...), "\t")
+}
+
+func checkEntry(entry Entry) error {
+	name := strings.TrimSpace(entry.Name)
+	if name == "" {
+		return errors.New("birthday: name required")
+	}
+	if name != entry.Name || strings.ContainsAny(name, "\t\r\n") {
+		return fmt.Errorf("birthday: invalid name: %q", entry.Name)
+	}
+	if strings.HasPrefix(name, "#") {
+		return fmt.Errorf("birthday: name may not start with '#': %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package birthday_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/keep94/birthday"
+	"github.com/keep94/consume2"
+	"github.com/keep94/toolbox/date_util"
+	asserts "github.com/stretchr/testify/assert"
+)
+
+const (
+	kWritableContents = `# Family birthdays
+
+Jack Sprat	08/31/2006	Tea
+Alice Doe	12/15
+`
+)
+
+func TestWritableStore(t *testing.T) {
+	assert := asserts.New(t)
+	store := newTestStore(t, kWritableContents)
+	entries := readAll(t, store)
+	assert.Len(entries, 2)
+
+	id, err := store.Add(
+		birthday.Entry{Name: "Bob Smith", Birthday: date_util.YMD(1971, 3, 5)})
+	assert.NoError(err)
+	_, err = store.Add(
+		birthday.Entry{Name: "Bob Smith", Birthday: date_util.YMD(1971, 3, 5)})
+	assert.Equal(birthday.ErrDuplicateEntry, err)
+
+	assert.NoError(store.Update(
+		entries[0].Id,
+		birthday.Entry{Name: "Jack Spratt", Birthday: date_util.YMD(2006, 8, 30)}))
+	assert.NoError(store.Delete(entries[1].Id))
+	assert.Equal(birthday.ErrNoSuchId, store.Delete(entries[1].Id))
+
+	assert.Equal(`# Family birthdays
+
+Jack Spratt	08/30/2006	Tea	id=ebb25728440b
+Bob Smith	03/05/1971
+`, readContents(t, store))
+
+	updatedId := entries[0].Id
+	entries = readAll(t, store)
+	assert.Len(entries, 2)
+	assert.Equal(updatedId, entries[0].Id)
+	assert.Equal(id, entries[1].Id)
+	assert.Equal("Bob Smith", entries[1].Name)
+}
+
+func TestWritableStoreUpdateKeepsExplicitId(t *testing.T) {
+	assert := asserts.New(t)
+	store := newTestStore(t, "Alice Doe\t12/15\tid=alice\n")
+	assert.NoError(store.Update(
+		"alice",
+		birthday.Entry{Name: "Alice Roe", Birthday: date_util.YMD(0, 12, 15)}))
+	assert.Equal("Alice Roe\t12/15\tid=alice\n", readContents(t, store))
+	assert.NoError(store.Delete("alice"))
+	assert.Equal("", readContents(t, store))
+}
+
+func TestWritableStoreNoTrailingNewline(t *testing.T) {
+	assert := asserts.New(t)
+	store := newTestStore(t, "Alice Doe\t12/15")
+	_, err := store.Add(
+		birthday.Entry{Name: "Bob Smith", Birthday: date_util.YMD(0, 3, 5)})
+	assert.NoError(err)
+	assert.Equal("Alice Doe\t12/15\nBob Smith\t03/05\n", readContents(t, store))
+}
+
+func TestWritableStoreBadEntry(t *testing.T) {
+	assert := asserts.New(t)
+	store := newTestStore(t, kWritableContents)
+	_, err := store.Add(birthday.Entry{Birthday: date_util.YMD(0, 3, 5)})
+	assert.Error(err)
+	_, err = store.Add(
+		birthday.Entry{Name: "Bob\tSmith", Birthday: date_util.YMD(0, 3, 5)})
+	assert.Error(err)
+	_, err = store.Add(
+		birthday.Entry{Name: "# Bob", Birthday: date_util.YMD(0, 3, 5)})
+	assert.Error(err)
+	assert.Equal(kWritableContents, readContents(t, store))
+}
+
+func newTestStore(t *testing.T, contents string) birthday.SystemStore {
+	path := filepath.Join(t.TempDir(), "birthdays.tsv")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return birthday.SystemStore(path)
+}
+
+func readAll(t *testing.T, store birthday.Store) []birthday.Entry {
+	var entries []birthday.Entry
+	if err := store.Read(consume2.AppendTo(&entries)); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func readContents(t *testing.T, store birthday.SystemStore) string {
+	contents, err := os.ReadFile(string(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(contents)
+}